@@ -0,0 +1,74 @@
+package main
+
+import (
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/models"
+)
+
+// currentUserID mengambil id user dari token pada request
+func currentUserID(c *fiber.Ctx) uint {
+    userID, _ := c.Locals("user_id").(float64)
+    return uint(userID)
+}
+
+// seesAllExams bernilai true untuk role yang tidak dibatasi ke ujian miliknya
+func seesAllExams(c *fiber.Ctx) bool {
+    role, _ := c.Locals("role").(string)
+    return auth.HasPermission(role, auth.PermExamsAll)
+}
+
+// managedExams membatasi query ujian untuk panel admin: admin dan pengawas
+// melihat semua ujian, guru hanya ujian miliknya
+func managedExams(c *fiber.Ctx, db *gorm.DB) *gorm.DB {
+    query := db.Model(&models.Exam{})
+    if !seesAllExams(c) {
+        query = query.Where("exams.owner_id = ?", currentUserID(c))
+    }
+    return query
+}
+
+// findManagedExam memuat ujian yang boleh diakses dari panel admin
+func findManagedExam(c *fiber.Ctx, db *gorm.DB, id interface{}) (models.Exam, error) {
+    var exam models.Exam
+    err := managedExams(c, db).First(&exam, id).Error
+    return exam, err
+}
+
+// findManagedQuestion memuat soal yang ujiannya boleh dikelola user
+func findManagedQuestion(c *fiber.Ctx, db *gorm.DB, id interface{}) (models.Question, error) {
+    var q models.Question
+    err := db.Where("exam_id IN (?)", managedExams(c, db).Select("exams.id")).First(&q, id).Error
+    return q, err
+}
+
+// managedGroups membatasi query group: guru hanya group miliknya
+func managedGroups(c *fiber.Ctx, db *gorm.DB) *gorm.DB {
+    query := db.Model(&models.Group{})
+    if !seesAllExams(c) {
+        query = query.Where("groups.owner_id = ?", currentUserID(c))
+    }
+    return query
+}
+
+// enrolledExams membatasi query ujian ke ujian yang ditugaskan ke group
+// tempat peserta terdaftar. Ujian yang belum ditugaskan ke group mana pun
+// terbuka untuk semua peserta, seperti sebelum ada group.
+func enrolledExams(db *gorm.DB, userID uint) *gorm.DB {
+    enrolled := db.Table("exam_groups").
+        Select("exam_groups.exam_id").
+        Joins("JOIN group_members ON group_members.group_id = exam_groups.group_id").
+        Where("group_members.user_id = ?", userID)
+    assigned := db.Table("exam_groups").Select("exam_groups.exam_id")
+    return db.Model(&models.Exam{}).Where("exams.id IN (?) OR exams.id NOT IN (?)", enrolled, assigned)
+}
+
+// examNotFound mengirim respons 404 yang sama untuk ujian yang tidak ada
+// maupun yang tidak boleh diakses, agar id ujian kelas lain tidak terungkap
+func examNotFound(c *fiber.Ctx) error {
+    return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+        "success": false,
+        "message": "Ujian tidak ditemukan",
+    })
+}
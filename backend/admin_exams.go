@@ -0,0 +1,397 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/models"
+)
+
+// optionalTime membedakan field waktu yang tidak dikirim dari yang dikirim
+// null: Set bernilai true hanya jika key ada di body request
+type optionalTime struct {
+    Set  bool
+    Time *time.Time
+}
+
+func (o *optionalTime) UnmarshalJSON(data []byte) error {
+    o.Set = true
+    return json.Unmarshal(data, &o.Time)
+}
+
+// examRequest adalah body request tambah/edit ujian. Field yang tidak dikirim
+// tidak diubah saat update.
+type examRequest struct {
+    Title         *string      `json:"title"`
+    Description   *string      `json:"description"`
+    Duration      *int         `json:"duration"`
+    OpensAt       optionalTime `json:"opens_at"` // ISO 8601 dengan zona waktu; null menghapus batas
+    ClosesAt      optionalTime `json:"closes_at"`
+    MaxAttempts   *int         `json:"max_attempts"`   // 1 untuk ujian baru jika kosong
+    GradingPolicy *string      `json:"grading_policy"` // last untuk ujian baru jika kosong
+}
+
+// applyTo menyalin field yang dikirim ke model ujian
+func (r examRequest) applyTo(e *models.Exam) {
+    if r.Title != nil {
+        e.Title = *r.Title
+    }
+    if r.Description != nil {
+        e.Description = *r.Description
+    }
+    if r.Duration != nil {
+        e.Duration = *r.Duration
+    }
+    if r.OpensAt.Set {
+        e.OpensAt = r.OpensAt.Time
+    }
+    if r.ClosesAt.Set {
+        e.ClosesAt = r.ClosesAt.Time
+    }
+    if r.MaxAttempts != nil {
+        e.MaxAttempts = *r.MaxAttempts
+    }
+    if r.GradingPolicy != nil {
+        e.GradingPolicy = *r.GradingPolicy
+    }
+}
+
+// registerAdminExamRoutes mendaftarkan CRUD ujian di bawah grup /api/admin
+func registerAdminExamRoutes(admin fiber.Router, db *gorm.DB) {
+    // List all exams
+    admin.Get("/exams", requirePermission(auth.PermExamsView), func(c *fiber.Ctx) error {
+        var exams []models.Exam
+        if err := managedExams(c, db).Order("id").Find(&exams).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil data ujian",
+            })
+        }
+        return c.JSON(exams)
+    })
+
+    // Get exam detail
+    admin.Get("/exams/:id", requirePermission(auth.PermExamsView), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        return c.JSON(exam)
+    })
+
+    // Add exam
+    admin.Post("/exams", requirePermission(auth.PermExamsManage), func(c *fiber.Ctx) error {
+        var req examRequest
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        exam := models.Exam{OwnerID: currentUserID(c), MaxAttempts: 1}
+        req.applyTo(&exam)
+        if err := exam.Validate(); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": err.Error(),
+            })
+        }
+        if err := db.Create(&exam).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menambah ujian",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Ujian berhasil ditambah",
+            "exam":    exam,
+        })
+    })
+
+    // Edit exam
+    admin.Put("/exams/:id", requirePermission(auth.PermExamsManage), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        var req examRequest
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        req.applyTo(&exam)
+        if err := exam.Validate(); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": err.Error(),
+            })
+        }
+        if err := db.Save(&exam).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal update ujian",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Ujian berhasil diupdate",
+            "exam":    exam,
+        })
+    })
+
+    // Delete exam beserta soalnya, ditolak jika sudah ada jawaban peserta
+    admin.Delete("/exams/:id", requirePermission(auth.PermExamsManage), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        var answerCount, submittedCount int64
+        err = db.Model(&models.Answer{}).
+            Where("question_id IN (?)", db.Model(&models.Question{}).Select("id").Where("exam_id = ?", exam.ID)).
+            Count(&answerCount).Error
+        if err == nil {
+            err = db.Model(&models.Attempt{}).Where("exam_id = ? AND status IN ?", exam.ID, models.FinishedAttemptStatuses).Count(&submittedCount).Error
+        }
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menghapus ujian",
+            })
+        }
+        if answerCount > 0 || submittedCount > 0 {
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "Ujian sudah memiliki jawaban peserta dan tidak dapat dihapus",
+            })
+        }
+        err = db.Transaction(func(tx *gorm.DB) error {
+            if err := tx.Where("exam_id = ?", exam.ID).Delete(&models.Attempt{}).Error; err != nil {
+                return err
+            }
+            if err := tx.Where("exam_id = ?", exam.ID).Delete(&models.ExamOverride{}).Error; err != nil {
+                return err
+            }
+            if err := tx.Where("exam_id = ?", exam.ID).Delete(&models.ExamGroup{}).Error; err != nil {
+                return err
+            }
+            if err := tx.Where("exam_id = ?", exam.ID).Delete(&models.Question{}).Error; err != nil {
+                return err
+            }
+            return tx.Delete(&exam).Error
+        })
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menghapus ujian",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Ujian berhasil dihapus",
+        })
+    })
+
+    // Clone exam beserta seluruh soalnya
+    admin.Post("/exams/:id/clone", requirePermission(auth.PermExamsManage), func(c *fiber.Ctx) error {
+        source, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        clone := models.Exam{
+            Title:         source.Title + " (Salinan)",
+            Description:   source.Description,
+            Duration:      source.Duration,
+            MaxAttempts:   source.MaxAttempts,
+            GradingPolicy: source.GradingPolicy,
+            OwnerID:       currentUserID(c),
+        }
+        err = db.Transaction(func(tx *gorm.DB) error {
+            if err := tx.Create(&clone).Error; err != nil {
+                return err
+            }
+            var questions []models.Question
+            if err := tx.Where("exam_id = ?", source.ID).Order("id").Find(&questions).Error; err != nil {
+                return err
+            }
+            for _, q := range questions {
+                q.ID = 0
+                q.ExamID = clone.ID
+                if err := tx.Create(&q).Error; err != nil {
+                    return err
+                }
+            }
+            return nil
+        })
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menyalin ujian",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Ujian berhasil disalin",
+            "exam":    clone,
+        })
+    })
+
+    // List per-participant overrides for an exam
+    admin.Get("/exams/:id/overrides", requirePermission(auth.PermExamsView), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        var overrides []models.ExamOverride
+        if err := db.Where("exam_id = ?", exam.ID).Order("user_id").Find(&overrides).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil penyesuaian waktu",
+            })
+        }
+        return c.JSON(overrides)
+    })
+
+    // Set (create or replace) a participant's extra time
+    admin.Put("/exams/:id/overrides/:user_id", requirePermission(auth.PermExamsManage), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        var user User
+        if err := db.First(&user, c.Params("user_id")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User tidak ditemukan",
+            })
+        } else if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menyimpan penyesuaian waktu",
+            })
+        }
+        var req struct {
+            ExtraTime int    `json:"extra_time"`
+            Note      string `json:"note"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        if req.ExtraTime < 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Tambahan waktu tidak boleh negatif",
+            })
+        }
+        var override models.ExamOverride
+        err = db.Where("exam_id = ? AND user_id = ?", exam.ID, user.ID).First(&override).Error
+        if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menyimpan penyesuaian waktu",
+            })
+        }
+        override.ExamID = exam.ID
+        override.UserID = user.ID
+        override.ExtraTime = req.ExtraTime
+        override.Note = req.Note
+        if err := db.Save(&override).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menyimpan penyesuaian waktu",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success":  true,
+            "message":  "Penyesuaian waktu berhasil disimpan",
+            "override": override,
+        })
+    })
+
+    // Remove a participant's override
+    admin.Delete("/exams/:id/overrides/:user_id", requirePermission(auth.PermExamsManage), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        if err := db.Where("exam_id = ? AND user_id = ?", exam.ID, c.Params("user_id")).Delete(&models.ExamOverride{}).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menghapus penyesuaian waktu",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Penyesuaian waktu berhasil dihapus",
+        })
+    })
+
+    // List results of all participants for an exam
+    admin.Get("/exams/:id/results", requirePermission(auth.PermResultsView), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        var results []struct {
+            ParticipantID uint      `json:"participant_id"`
+            Name          string    `json:"name"`
+            Email         string    `json:"email"`
+            Score         float64   `json:"score"`
+            MaxScore      float64   `json:"max_score"`
+            Attempts      int       `json:"attempts"`
+            Status        string    `json:"status"`
+            GradedAt      time.Time `json:"graded_at"`
+        }
+        err = db.Table("results").
+            Select("results.participant_id, users.name, users.email, results.score, results.max_score, results.attempts, results.status, results.graded_at").
+            Joins("LEFT JOIN users ON users.id = results.participant_id").
+            Where("results.exam_id = ?", exam.ID).
+            Order("results.participant_id").
+            Scan(&results).Error
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil hasil ujian",
+            })
+        }
+        return c.JSON(results)
+    })
+
+    // Monitor sesi yang sedang berjalan untuk pengawas ujian
+    admin.Get("/exams/:id/sessions", requirePermission(auth.PermSessionsMonitor), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        var live []struct {
+            AttemptID     uint      `json:"attempt_id"`
+            ParticipantID uint      `json:"participant_id"`
+            Name          string    `json:"name"`
+            Email         string    `json:"email"`
+            StartedAt     time.Time `json:"started_at"`
+            Deadline      time.Time `json:"deadline"`
+        }
+        err = db.Table("attempts").
+            Select("attempts.id AS attempt_id, attempts.participant_id, users.name, users.email, attempts.started_at, attempts.deadline").
+            Joins("LEFT JOIN users ON users.id = attempts.participant_id").
+            Where("attempts.exam_id = ? AND attempts.status = ?", exam.ID, models.AttemptInProgress).
+            Order("attempts.deadline").
+            Scan(&live).Error
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil sesi ujian",
+            })
+        }
+        return c.JSON(live)
+    })
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+    "encoding/json"
+    "testing"
+    "time"
+
+    "online-exam-app-backend/models"
+)
+
+func TestExamRequestUpdatesOnlySentFields(t *testing.T) {
+    opens := time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC)
+    closes := opens.Add(4 * time.Hour)
+    original := models.Exam{Title: "Matematika", Description: "UTS", Duration: 3600, OpensAt: &opens, ClosesAt: &closes, MaxAttempts: 2, GradingPolicy: models.GradingBest}
+
+    tests := []struct {
+        name  string
+        body  string
+        check func(e models.Exam) bool
+    }{
+        {name: "body kosong tidak mengubah apa pun", body: `{}`, check: func(e models.Exam) bool {
+            return e.Title == "Matematika" && e.Duration == 3600 && e.OpensAt == &opens && e.ClosesAt == &closes && e.MaxAttempts == 2 && e.GradingPolicy == models.GradingBest
+        }},
+        {name: "hanya judul", body: `{"title":"Fisika"}`, check: func(e models.Exam) bool {
+            return e.Title == "Fisika" && e.Description == "UTS" && e.OpensAt == &opens && e.ClosesAt == &closes
+        }},
+        {name: "null menghapus batas waktu", body: `{"closes_at":null}`, check: func(e models.Exam) bool {
+            return e.OpensAt == &opens && e.ClosesAt == nil
+        }},
+        {name: "waktu baru", body: `{"opens_at":"2024-02-01T07:00:00+07:00"}`, check: func(e models.Exam) bool {
+            return e.OpensAt != nil && e.OpensAt.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) && e.ClosesAt == &closes
+        }},
+        {name: "max_attempts 0 berarti tanpa batas", body: `{"max_attempts":0}`, check: func(e models.Exam) bool {
+            return e.MaxAttempts == 0 && e.Duration == 3600
+        }},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var req examRequest
+            if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+                t.Fatal(err)
+            }
+            exam := original
+            req.applyTo(&exam)
+            if !tt.check(exam) {
+                t.Errorf("hasil update %s tidak sesuai: %+v", tt.body, exam)
+            }
+        })
+    }
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+    "fmt"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/grading"
+    "online-exam-app-backend/models"
+)
+
+// manualQuestionTypes adalah tipe soal yang masuk antrean penilaian manual
+var manualQuestionTypes = []string{models.QuestionEssay, models.QuestionShortAnswer}
+
+// visibleScore menyembunyikan skor sementara dari peserta selama masih ada
+// jawaban yang menunggu dinilai manual
+func visibleScore(status string, score float64) interface{} {
+    if status == models.ResultPending {
+        return nil
+    }
+    return score
+}
+
+// gradingItem adalah satu jawaban final di antrean penilaian manual
+type gradingItem struct {
+    AnswerID         uint                     `json:"answer_id"`
+    AttemptID        uint                     `json:"attempt_id"`
+    ExamID           uint                     `json:"exam_id"`
+    ParticipantID    uint                     `json:"participant_id"`
+    ParticipantEmail string                   `json:"participant_email"`
+    QuestionID       uint                     `json:"question_id"`
+    QuestionText     string                   `json:"question_text"`
+    AnswerText       string                   `json:"answer_text"`
+    SubmittedAt      time.Time                `json:"submitted_at"`
+    MaxPoints        float64                  `json:"max_points" gorm:"-"`
+    Rubric           []models.RubricCriterion `json:"rubric" gorm:"-"`
+    Grade            *models.AnswerGrade      `json:"grade" gorm:"-"`
+}
+
+// manualAnswers membangun query jawaban final soal esai dan jawaban singkat
+// dari attempt yang sudah dikumpulkan, dibatasi ke ujian yang dikelola user.
+// Jawaban kosong tidak perlu dinilai sehingga tidak ikut antrean.
+func manualAnswers(c *fiber.Ctx, db *gorm.DB) *gorm.DB {
+    return db.Table("answers").
+        Joins("JOIN questions ON questions.id = answers.question_id").
+        Joins("JOIN attempts ON attempts.id = answers.attempt_id").
+        Joins("LEFT JOIN answer_grades ON answer_grades.answer_id = answers.id").
+        Where("answers.is_draft = ? AND TRIM(answers.answer_text) <> ''", false).
+        Where("attempts.status IN ?", models.FinishedAttemptStatuses).
+        Where("questions.type IN ?", manualQuestionTypes).
+        Where("questions.exam_id IN (?)", managedExams(c, db).Select("exams.id"))
+}
+
+// registerAdminGradingRoutes mendaftarkan antrean dan penilaian manual
+// jawaban esai di bawah grup /api/admin
+func registerAdminGradingRoutes(admin fiber.Router, db *gorm.DB) {
+    // Ringkasan jumlah jawaban yang belum dinilai per ujian
+    admin.Get("/grading", requirePermission(auth.PermResultsGrade), func(c *fiber.Ctx) error {
+        var summary []struct {
+            ExamID   uint   `json:"exam_id"`
+            Title    string `json:"title"`
+            Ungraded int    `json:"ungraded"`
+        }
+        err := manualAnswers(c, db).
+            Joins("JOIN exams ON exams.id = questions.exam_id").
+            Where("answer_grades.id IS NULL").
+            Select("questions.exam_id AS exam_id, exams.title AS title, COUNT(*) AS ungraded").
+            Group("questions.exam_id, exams.title").
+            Order("questions.exam_id").
+            Scan(&summary).Error
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil antrean penilaian",
+            })
+        }
+        return c.JSON(summary)
+    })
+
+    // Daftar jawaban yang perlu dinilai untuk satu ujian.
+    // ?status=ungraded (default), graded, atau all
+    admin.Get("/grading/exams/:id", requirePermission(auth.PermResultsGrade), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        query := manualAnswers(c, db).
+            Joins("JOIN users ON users.id = attempts.participant_id").
+            Where("questions.exam_id = ?", exam.ID)
+        switch c.Query("status", "ungraded") {
+        case "ungraded":
+            query = query.Where("answer_grades.id IS NULL")
+        case "graded":
+            query = query.Where("answer_grades.id IS NOT NULL")
+        case "all":
+        default:
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Status harus ungraded, graded, atau all",
+            })
+        }
+        var items []gradingItem
+        err = query.
+            Select("answers.id AS answer_id, answers.attempt_id, questions.exam_id, attempts.participant_id, " +
+                "users.email AS participant_email, answers.question_id, questions.question_text, " +
+                "answers.answer_text, answers.submitted_at").
+            Order("answers.submitted_at, answers.id").
+            Scan(&items).Error
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil antrean penilaian",
+            })
+        }
+
+        // Lengkapi dengan bobot, rubrik dan nilai yang sudah diberikan
+        var questions []models.Question
+        if err := db.Where("exam_id = ? AND type IN ?", exam.ID, manualQuestionTypes).Find(&questions).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil soal",
+            })
+        }
+        byID := make(map[uint]models.Question, len(questions))
+        for _, q := range questions {
+            byID[q.ID] = q
+        }
+        answerIDs := make([]uint, 0, len(items))
+        for _, item := range items {
+            answerIDs = append(answerIDs, item.AnswerID)
+        }
+        var grades []models.AnswerGrade
+        if len(answerIDs) > 0 {
+            if err := db.Where("answer_id IN ?", answerIDs).Find(&grades).Error; err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Gagal mengambil nilai",
+                })
+            }
+        }
+        gradeOf := make(map[uint]*models.AnswerGrade, len(grades))
+        for i := range grades {
+            gradeOf[grades[i].AnswerID] = &grades[i]
+        }
+        for i := range items {
+            q := byID[items[i].QuestionID]
+            items[i].MaxPoints = grading.MaxPoints(q)
+            items[i].Rubric = q.Rubric
+            items[i].Grade = gradeOf[items[i].AnswerID]
+        }
+        return c.JSON(items)
+    })
+
+    // Beri atau ubah nilai manual satu jawaban
+    admin.Post("/grading/answers/:id", requirePermission(auth.PermResultsGrade), func(c *fiber.Ctx) error {
+        var req struct {
+            Points       *float64  `json:"points"`        // dipakai jika soal tidak memiliki rubrik
+            RubricScores []float64 `json:"rubric_scores"` // poin per kriteria rubrik
+            Comment      string    `json:"comment"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+
+        var item struct {
+            AnswerID   uint
+            AttemptID  uint
+            QuestionID uint
+        }
+        err := manualAnswers(c, db).
+            Where("answers.id = ?", c.Params("id")).
+            Select("answers.id AS answer_id, answers.attempt_id, answers.question_id").
+            Take(&item).Error
+        if err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Jawaban tidak ditemukan",
+            })
+        }
+        var question models.Question
+        if err := db.First(&question, item.QuestionID).Error; err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Soal tidak ditemukan",
+            })
+        }
+
+        max := grading.MaxPoints(question)
+        grade := models.AnswerGrade{
+            AnswerID: item.AnswerID,
+            GraderID: currentUserID(c),
+            Comment:  req.Comment,
+            GradedAt: time.Now(),
+        }
+        if len(question.Rubric) > 0 {
+            if len(req.RubricScores) != len(question.Rubric) {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Nilai rubrik harus diisi untuk %d kriteria", len(question.Rubric)),
+                })
+            }
+            var total float64
+            for i, score := range req.RubricScores {
+                criterion := question.Rubric[i]
+                if score < 0 || score > criterion.Points {
+                    return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                        "success": false,
+                        "message": fmt.Sprintf("Nilai kriteria %q harus antara 0 dan %g", criterion.Criterion, criterion.Points),
+                    })
+                }
+                total += score
+            }
+            grade.RubricScores = req.RubricScores
+            grade.Points = max * total / question.RubricMax()
+        } else {
+            if req.Points == nil || *req.Points < 0 || *req.Points > max {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Poin harus antara 0 dan %g", max),
+                })
+            }
+            grade.Points = *req.Points
+        }
+
+        var attempt models.Attempt
+        err = db.Transaction(func(tx *gorm.DB) error {
+            // Kunci attempt agar penilaian jawaban lain pada attempt yang sama berurutan
+            if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&attempt, item.AttemptID).Error; err != nil {
+                return err
+            }
+            err := tx.Clauses(clause.OnConflict{
+                Columns:   []clause.Column{{Name: "answer_id"}},
+                DoUpdates: clause.AssignmentColumns([]string{"grader_id", "points", "rubric_scores", "comment", "graded_at"}),
+            }).Create(&grade).Error
+            if err != nil {
+                return err
+            }
+            _, err = grading.GradeAttempt(tx, &attempt)
+            return err
+        })
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menyimpan nilai",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success":       true,
+            "message":       "Jawaban berhasil dinilai",
+            "grade":         grade,
+            "attempt_id":    attempt.ID,
+            "result_status": attempt.ResultStatus,
+            "score":         attempt.Score,
+            "max_score":     attempt.MaxScore,
+        })
+    })
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+    "testing"
+
+    "online-exam-app-backend/models"
+)
+
+func TestPendingResultHidesScore(t *testing.T) {
+    result := models.Result{Score: 3, MaxScore: 5, Status: models.ResultPending}
+    if score := resultResponse(result)["score"]; score != nil {
+        t.Errorf("skor sementara tidak boleh terlihat selama penilaian manual, dapat %v", score)
+    }
+}
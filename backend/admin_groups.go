@@ -0,0 +1,374 @@
+package main
+
+import (
+    "strings"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/models"
+)
+
+// registerAdminGroupRoutes mendaftarkan pengelolaan group (kelas), anggota
+// group, dan penugasan ujian ke group di bawah grup /api/admin
+func registerAdminGroupRoutes(admin fiber.Router, db *gorm.DB) {
+    groupNotFound := func(c *fiber.Ctx) error {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "success": false,
+            "message": "Group tidak ditemukan",
+        })
+    }
+    findGroup := func(c *fiber.Ctx) (models.Group, error) {
+        var group models.Group
+        err := managedGroups(c, db).First(&group, c.Params("id")).Error
+        return group, err
+    }
+
+    // List groups
+    admin.Get("/groups", requirePermission(auth.PermGroupsManage), func(c *fiber.Ctx) error {
+        var groups []models.Group
+        if err := managedGroups(c, db).Order("id").Find(&groups).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil data group",
+            })
+        }
+        return c.JSON(groups)
+    })
+
+    // Add group
+    admin.Post("/groups", requirePermission(auth.PermGroupsManage), func(c *fiber.Ctx) error {
+        var req struct {
+            Name        string `json:"name"`
+            Description string `json:"description"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        group := models.Group{Name: req.Name, Description: req.Description, OwnerID: currentUserID(c)}
+        if err := group.Validate(); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": err.Error(),
+            })
+        }
+        if err := db.Create(&group).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menambah group",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Group berhasil ditambah",
+            "group":   group,
+        })
+    })
+
+    // Edit group
+    admin.Put("/groups/:id", requirePermission(auth.PermGroupsManage), func(c *fiber.Ctx) error {
+        group, err := findGroup(c)
+        if err != nil {
+            return groupNotFound(c)
+        }
+        var req struct {
+            Name        string `json:"name"`
+            Description string `json:"description"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        group.Name = req.Name
+        group.Description = req.Description
+        if err := group.Validate(); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": err.Error(),
+            })
+        }
+        if err := db.Save(&group).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal update group",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Group berhasil diupdate",
+            "group":   group,
+        })
+    })
+
+    // Delete group beserta keanggotaan dan penugasan ujiannya
+    admin.Delete("/groups/:id", requirePermission(auth.PermGroupsManage), func(c *fiber.Ctx) error {
+        group, err := findGroup(c)
+        if err != nil {
+            return groupNotFound(c)
+        }
+        err = db.Transaction(func(tx *gorm.DB) error {
+            if err := tx.Where("group_id = ?", group.ID).Delete(&models.GroupMember{}).Error; err != nil {
+                return err
+            }
+            if err := tx.Where("group_id = ?", group.ID).Delete(&models.ExamGroup{}).Error; err != nil {
+                return err
+            }
+            return tx.Delete(&group).Error
+        })
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menghapus group",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Group berhasil dihapus",
+        })
+    })
+
+    // List group members
+    admin.Get("/groups/:id/members", requirePermission(auth.PermGroupsManage), func(c *fiber.Ctx) error {
+        group, err := findGroup(c)
+        if err != nil {
+            return groupNotFound(c)
+        }
+        var members []struct {
+            ID    uint   `json:"id"`
+            Name  string `json:"name"`
+            Email string `json:"email"`
+            Role  string `json:"role"`
+        }
+        err = db.Table("group_members").
+            Select("users.id, users.name, users.email, users.role").
+            Joins("JOIN users ON users.id = group_members.user_id").
+            Where("group_members.group_id = ?", group.ID).
+            Order("users.name, users.id").
+            Scan(&members).Error
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil anggota group",
+            })
+        }
+        return c.JSON(members)
+    })
+
+    // Enroll participants berdasarkan id user atau email
+    admin.Post("/groups/:id/members", requirePermission(auth.PermGroupsManage), func(c *fiber.Ctx) error {
+        group, err := findGroup(c)
+        if err != nil {
+            return groupNotFound(c)
+        }
+        var req struct {
+            UserIDs []uint   `json:"user_ids"`
+            Emails  []string `json:"emails"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        emails := make([]string, 0, len(req.Emails))
+        for _, e := range req.Emails {
+            if e = strings.TrimSpace(e); e != "" {
+                emails = append(emails, e)
+            }
+        }
+        if len(req.UserIDs) == 0 && len(emails) == 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "user_ids atau emails wajib diisi",
+            })
+        }
+
+        var users []User
+        query := db.Select("id", "email", "role")
+        if len(req.UserIDs) > 0 && len(emails) > 0 {
+            query = query.Where("id IN ? OR email IN ?", req.UserIDs, emails)
+        } else if len(req.UserIDs) > 0 {
+            query = query.Where("id IN ?", req.UserIDs)
+        } else {
+            query = query.Where("email IN ?", emails)
+        }
+        if err := query.Find(&users).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menambah anggota group",
+            })
+        }
+
+        // Laporkan email yang tidak terdaftar agar bisa diperbaiki, dan
+        // tolak akun non-participant (admin, teacher, proctor)
+        found := make(map[string]bool, len(users))
+        candidates := make([]uint, 0, len(users))
+        notParticipant := []uint{}
+        for _, u := range users {
+            found[strings.ToLower(u.Email)] = true
+            if auth.NormalizeRole(u.Role) != auth.RoleParticipant {
+                notParticipant = append(notParticipant, u.ID)
+                continue
+            }
+            candidates = append(candidates, u.ID)
+        }
+        notFound := []string{}
+        for _, e := range emails {
+            if !found[strings.ToLower(e)] {
+                notFound = append(notFound, e)
+            }
+        }
+
+        // Hanya user yang belum menjadi anggota yang dilaporkan sebagai baru
+        enrolledIDs := []uint{}
+        alreadyMember := []uint{}
+        var enrolled int64
+        if len(candidates) > 0 {
+            var existing []uint
+            err := db.Model(&models.GroupMember{}).
+                Where("group_id = ? AND user_id IN ?", group.ID, candidates).
+                Pluck("user_id", &existing).Error
+            if err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Gagal menambah anggota group",
+                })
+            }
+            isMember := make(map[uint]bool, len(existing))
+            for _, id := range existing {
+                isMember[id] = true
+            }
+            members := make([]models.GroupMember, 0, len(candidates))
+            for _, id := range candidates {
+                if isMember[id] {
+                    alreadyMember = append(alreadyMember, id)
+                    continue
+                }
+                enrolledIDs = append(enrolledIDs, id)
+                members = append(members, models.GroupMember{GroupID: group.ID, UserID: id})
+            }
+            if len(members) > 0 {
+                // Request bersamaan bisa mendahului; RowsAffected hanya menghitung baris baru
+                result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&members)
+                if result.Error != nil {
+                    return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                        "success": false,
+                        "message": "Gagal menambah anggota group",
+                    })
+                }
+                enrolled = result.RowsAffected
+            }
+        }
+        return c.JSON(fiber.Map{
+            "success":         true,
+            "message":         "Anggota group berhasil ditambah",
+            "enrolled":        enrolled,
+            "enrolled_ids":    enrolledIDs,
+            "already_members": alreadyMember,
+            "not_participant": notParticipant,
+            "not_found":       notFound,
+        })
+    })
+
+    // Remove a member from a group
+    admin.Delete("/groups/:id/members/:user_id", requirePermission(auth.PermGroupsManage), func(c *fiber.Ctx) error {
+        group, err := findGroup(c)
+        if err != nil {
+            return groupNotFound(c)
+        }
+        if err := db.Where("group_id = ? AND user_id = ?", group.ID, c.Params("user_id")).Delete(&models.GroupMember{}).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menghapus anggota group",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Anggota group berhasil dihapus",
+        })
+    })
+
+    // List groups assigned to an exam
+    admin.Get("/exams/:id/groups", requirePermission(auth.PermExamsView), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        var groups []models.Group
+        err = db.Where("id IN (?)", db.Model(&models.ExamGroup{}).Select("group_id").Where("exam_id = ?", exam.ID)).
+            Order("id").Find(&groups).Error
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil data group",
+            })
+        }
+        return c.JSON(groups)
+    })
+
+    // Replace the set of groups assigned to an exam
+    admin.Put("/exams/:id/groups", requirePermission(auth.PermExamsManage), func(c *fiber.Ctx) error {
+        exam, err := findManagedExam(c, db, c.Params("id"))
+        if err != nil {
+            return examNotFound(c)
+        }
+        var req struct {
+            GroupIDs []uint `json:"group_ids"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        // Guru hanya boleh menugaskan ujian ke group miliknya
+        var allowed int64
+        if len(req.GroupIDs) > 0 {
+            if err := managedGroups(c, db).Where("id IN ?", req.GroupIDs).Count(&allowed).Error; err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Gagal menyimpan penugasan ujian",
+                })
+            }
+        }
+        links := make([]models.ExamGroup, 0, len(req.GroupIDs))
+        groupIDs := make([]uint, 0, len(req.GroupIDs))
+        seen := make(map[uint]bool, len(req.GroupIDs))
+        for _, id := range req.GroupIDs {
+            if !seen[id] {
+                seen[id] = true
+                links = append(links, models.ExamGroup{ExamID: exam.ID, GroupID: id})
+                groupIDs = append(groupIDs, id)
+            }
+        }
+        if int(allowed) != len(links) {
+            return groupNotFound(c)
+        }
+        err = db.Transaction(func(tx *gorm.DB) error {
+            if err := tx.Where("exam_id = ?", exam.ID).Delete(&models.ExamGroup{}).Error; err != nil {
+                return err
+            }
+            if len(links) == 0 {
+                return nil
+            }
+            return tx.Create(&links).Error
+        })
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menyimpan penugasan ujian",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success":   true,
+            "message":   "Penugasan ujian berhasil disimpan",
+            "group_ids": groupIDs,
+        })
+    })
+}
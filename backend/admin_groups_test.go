@@ -0,0 +1,39 @@
+package main
+
+import (
+    "fmt"
+    "testing"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/models"
+)
+
+func TestAssignExamGroupsReturnsStoredIDs(t *testing.T) {
+    app := newTestApp(t, func(app fiber.Router, db *gorm.DB) {
+        registerAdminGroupRoutes(app.Group("/api/admin", authMiddleware), db)
+    })
+    teacher, token := newTestUser(t, "guru@example.com", auth.RoleTeacher)
+
+    exam := models.Exam{Title: "Ujian", Duration: 600, OwnerID: teacher.ID, GradingPolicy: "last"}
+    db.Create(&exam)
+    first, second := models.Group{Name: "A", OwnerID: teacher.ID}, models.Group{Name: "B", OwnerID: teacher.ID}
+    db.Create(&first)
+    db.Create(&second)
+
+    path := fmt.Sprintf("/api/admin/exams/%d/groups", exam.ID)
+    status, body := callJSON(t, app, "PUT", path, token, fiber.Map{"group_ids": []uint{second.ID, first.ID, second.ID}})
+    if status != fiber.StatusOK {
+        t.Fatalf("status = %d, body = %v", status, body)
+    }
+    got := fmt.Sprint(body.(map[string]interface{})["group_ids"])
+    if want := fmt.Sprint([]interface{}{float64(second.ID), float64(first.ID)}); got != want {
+        t.Errorf("group_ids = %s, harus %s", got, want)
+    }
+    var stored int64
+    db.Model(&models.ExamGroup{}).Where("exam_id = ?", exam.ID).Count(&stored)
+    if stored != 2 {
+        t.Errorf("jumlah penugasan tersimpan = %d, harus 2", stored)
+    }
+}
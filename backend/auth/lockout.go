@@ -0,0 +1,77 @@
+package auth
+
+import (
+    "context"
+    "strings"
+    "time"
+
+    goredis "github.com/redis/go-redis/v9"
+)
+
+// LoginGuard mengunci akun sementara setelah terlalu banyak login gagal.
+// Penghitung gagal dan tanda kunci disimpan di Redis dengan TTL.
+type LoginGuard struct {
+    rdb         *goredis.Client
+    maxFailures int
+    window      time.Duration
+    lockout     time.Duration
+}
+
+// NewLoginGuard membuat LoginGuard. maxFailures <= 0 mematikan penguncian.
+func NewLoginGuard(rdb *goredis.Client, maxFailures int, window, lockout time.Duration) *LoginGuard {
+    return &LoginGuard{rdb: rdb, maxFailures: maxFailures, window: window, lockout: lockout}
+}
+
+// LockedFor mengembalikan sisa waktu kunci akun, atau 0 jika tidak terkunci
+func (g *LoginGuard) LockedFor(email string) (time.Duration, error) {
+    if g.maxFailures <= 0 {
+        return 0, nil
+    }
+    ttl, err := g.rdb.PTTL(context.Background(), lockKey(email)).Result()
+    if err != nil || ttl < 0 {
+        return 0, err
+    }
+    return ttl, nil
+}
+
+// RecordFailure mencatat satu login gagal dan mengunci akun jika batas
+// tercapai. Mengembalikan durasi kunci jika akun baru saja dikunci.
+func (g *LoginGuard) RecordFailure(email string) (time.Duration, error) {
+    if g.maxFailures <= 0 {
+        return 0, nil
+    }
+    ctx := context.Background()
+    key := failuresKey(email)
+    pipe := g.rdb.TxPipeline()
+    incr := pipe.Incr(ctx, key)
+    pipe.ExpireNX(ctx, key, g.window)
+    if _, err := pipe.Exec(ctx); err != nil {
+        return 0, err
+    }
+    if incr.Val() < int64(g.maxFailures) {
+        return 0, nil
+    }
+    pipe = g.rdb.TxPipeline()
+    pipe.Set(ctx, lockKey(email), "1", g.lockout)
+    pipe.Del(ctx, key)
+    if _, err := pipe.Exec(ctx); err != nil {
+        return 0, err
+    }
+    return g.lockout, nil
+}
+
+// Reset menghapus penghitung login gagal setelah login berhasil
+func (g *LoginGuard) Reset(email string) error {
+    if g.maxFailures <= 0 {
+        return nil
+    }
+    return g.rdb.Del(context.Background(), failuresKey(email)).Err()
+}
+
+func failuresKey(email string) string {
+    return "login_failures:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func lockKey(email string) string {
+    return "login_locked:" + strings.ToLower(strings.TrimSpace(email))
+}
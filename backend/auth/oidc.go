@@ -0,0 +1,310 @@
+package auth
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rsa"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "net/http"
+    "net/url"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/golang-jwt/jwt/v4"
+)
+
+// OIDCConfig berisi pengaturan client OpenID Connect
+type OIDCConfig struct {
+    Issuer       string
+    ClientID     string
+    ClientSecret string // kosong untuk public client yang hanya memakai PKCE
+    RedirectURL  string
+    Scopes       []string
+    GroupsClaim  string
+}
+
+// OIDCIdentity adalah klaim dari ID token yang dipakai aplikasi
+type OIDCIdentity struct {
+    Subject       string
+    Email         string
+    EmailVerified bool
+    Name          string
+    Groups        []string
+}
+
+type oidcDiscovery struct {
+    Issuer                string `json:"issuer"`
+    AuthorizationEndpoint string `json:"authorization_endpoint"`
+    TokenEndpoint         string `json:"token_endpoint"`
+    JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider menjalankan alur authorization code + PKCE terhadap identity
+// provider. Discovery dan JWKS diambil saat pertama dipakai lalu di-cache,
+// sehingga aplikasi tetap bisa start walaupun provider belum siap.
+type OIDCProvider struct {
+    cfg    OIDCConfig
+    client *http.Client
+
+    mu        sync.Mutex
+    discovery *oidcDiscovery
+    keys      map[string]interface{}
+    keysAt    time.Time
+}
+
+// NewOIDCProvider membuat provider dari konfigurasi
+func NewOIDCProvider(cfg OIDCConfig) *OIDCProvider {
+    if len(cfg.Scopes) == 0 {
+        cfg.Scopes = []string{"openid", "email", "profile"}
+    }
+    if cfg.GroupsClaim == "" {
+        cfg.GroupsClaim = "groups"
+    }
+    cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+    return &OIDCProvider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NewPKCE membuat code verifier dan code challenge S256 (RFC 7636)
+func NewPKCE() (verifier, challenge string, err error) {
+    verifier, err = randomString(32)
+    if err != nil {
+        return "", "", err
+    }
+    sum := sha256.Sum256([]byte(verifier))
+    return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// AuthCodeURL membuat URL login di identity provider
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+    d, err := p.discover()
+    if err != nil {
+        return "", err
+    }
+    v := url.Values{}
+    v.Set("response_type", "code")
+    v.Set("client_id", p.cfg.ClientID)
+    v.Set("redirect_uri", p.cfg.RedirectURL)
+    v.Set("scope", strings.Join(p.cfg.Scopes, " "))
+    v.Set("state", state)
+    v.Set("nonce", nonce)
+    v.Set("code_challenge", codeChallenge)
+    v.Set("code_challenge_method", "S256")
+    sep := "?"
+    if strings.Contains(d.AuthorizationEndpoint, "?") {
+        sep = "&"
+    }
+    return d.AuthorizationEndpoint + sep + v.Encode(), nil
+}
+
+// Exchange menukar authorization code dengan token, lalu memverifikasi ID
+// token (tanda tangan, issuer, audience, masa berlaku dan nonce)
+func (p *OIDCProvider) Exchange(code, codeVerifier, nonce string) (*OIDCIdentity, error) {
+    d, err := p.discover()
+    if err != nil {
+        return nil, err
+    }
+    form := url.Values{}
+    form.Set("grant_type", "authorization_code")
+    form.Set("code", code)
+    form.Set("redirect_uri", p.cfg.RedirectURL)
+    form.Set("client_id", p.cfg.ClientID)
+    form.Set("code_verifier", codeVerifier)
+    if p.cfg.ClientSecret != "" {
+        form.Set("client_secret", p.cfg.ClientSecret)
+    }
+    resp, err := p.client.PostForm(d.TokenEndpoint, form)
+    if err != nil {
+        return nil, fmt.Errorf("gagal menghubungi token endpoint OIDC: %w", err)
+    }
+    defer resp.Body.Close()
+    var body struct {
+        IDToken          string `json:"id_token"`
+        Error            string `json:"error"`
+        ErrorDescription string `json:"error_description"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+        return nil, fmt.Errorf("respons token endpoint OIDC tidak valid: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+        return nil, fmt.Errorf("token endpoint OIDC menolak: %s %s", body.Error, body.ErrorDescription)
+    }
+    return p.verifyIDToken(body.IDToken, d.Issuer, nonce)
+}
+
+func (p *OIDCProvider) verifyIDToken(raw, issuer, nonce string) (*OIDCIdentity, error) {
+    claims := jwt.MapClaims{}
+    parser := jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}}
+    _, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+        kid, _ := token.Header["kid"].(string)
+        return p.key(kid)
+    })
+    if err != nil {
+        return nil, fmt.Errorf("ID token OIDC tidak valid: %w", err)
+    }
+    if !claims.VerifyIssuer(issuer, true) {
+        return nil, errors.New("issuer ID token OIDC tidak cocok")
+    }
+    if !claims.VerifyAudience(p.cfg.ClientID, true) {
+        return nil, errors.New("audience ID token OIDC tidak cocok")
+    }
+    if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+        return nil, errors.New("nonce ID token OIDC tidak cocok")
+    }
+
+    id := &OIDCIdentity{}
+    id.Subject, _ = claims["sub"].(string)
+    id.Email, _ = claims["email"].(string)
+    id.Name, _ = claims["name"].(string)
+    // Sebagian provider mengirim email_verified sebagai string
+    switch v := claims["email_verified"].(type) {
+    case bool:
+        id.EmailVerified = v
+    case string:
+        id.EmailVerified = v == "true"
+    }
+    switch v := claims[p.cfg.GroupsClaim].(type) {
+    case []interface{}:
+        for _, g := range v {
+            if s, ok := g.(string); ok {
+                id.Groups = append(id.Groups, s)
+            }
+        }
+    case string:
+        id.Groups = strings.Fields(strings.ReplaceAll(v, ",", " "))
+    }
+    if id.Subject == "" {
+        return nil, errors.New("ID token OIDC tidak memiliki sub")
+    }
+    return id, nil
+}
+
+func (p *OIDCProvider) discover() (*oidcDiscovery, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if p.discovery != nil {
+        return p.discovery, nil
+    }
+    var d oidcDiscovery
+    if err := p.getJSON(p.cfg.Issuer+"/.well-known/openid-configuration", &d); err != nil {
+        return nil, err
+    }
+    if strings.TrimSuffix(d.Issuer, "/") != p.cfg.Issuer {
+        return nil, fmt.Errorf("issuer discovery OIDC %q tidak sama dengan OIDC_ISSUER", d.Issuer)
+    }
+    p.discovery = &d
+    return p.discovery, nil
+}
+
+// key mencari kunci publik di JWKS. JWKS diambil ulang jika kid belum
+// dikenal (rotasi kunci di provider), paling sering sekali per menit.
+func (p *OIDCProvider) key(kid string) (interface{}, error) {
+    d, err := p.discover()
+    if err != nil {
+        return nil, err
+    }
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if k, ok := p.lookupKey(kid); ok {
+        return k, nil
+    }
+    if time.Since(p.keysAt) < time.Minute && p.keys != nil {
+        return nil, fmt.Errorf("kid %q tidak ada di JWKS OIDC", kid)
+    }
+    var set struct {
+        Keys []jsonWebKey `json:"keys"`
+    }
+    if err := p.getJSON(d.JWKSURI, &set); err != nil {
+        return nil, err
+    }
+    p.keys = map[string]interface{}{}
+    p.keysAt = time.Now()
+    for _, jwk := range set.Keys {
+        if jwk.Use != "" && jwk.Use != "sig" {
+            continue
+        }
+        if pub, err := jwk.publicKey(); err == nil {
+            p.keys[jwk.Kid] = pub
+        }
+    }
+    if k, ok := p.lookupKey(kid); ok {
+        return k, nil
+    }
+    return nil, fmt.Errorf("kid %q tidak ada di JWKS OIDC", kid)
+}
+
+// lookupKey menerima token tanpa kid hanya jika JWKS berisi satu kunci
+func (p *OIDCProvider) lookupKey(kid string) (interface{}, bool) {
+    if kid == "" && len(p.keys) == 1 {
+        for _, k := range p.keys {
+            return k, true
+        }
+    }
+    k, ok := p.keys[kid]
+    return k, ok
+}
+
+func (p *OIDCProvider) getJSON(u string, v interface{}) error {
+    resp, err := p.client.Get(u)
+    if err != nil {
+        return fmt.Errorf("gagal menghubungi provider OIDC: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("provider OIDC mengembalikan status %d untuk %s", resp.StatusCode, u)
+    }
+    return json.NewDecoder(resp.Body).Decode(v)
+}
+
+type jsonWebKey struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use"`
+    N   string `json:"n"`
+    E   string `json:"e"`
+    Crv string `json:"crv"`
+    X   string `json:"x"`
+    Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+    switch k.Kty {
+    case "RSA":
+        n, err := base64.RawURLEncoding.DecodeString(k.N)
+        if err != nil {
+            return nil, err
+        }
+        e, err := base64.RawURLEncoding.DecodeString(k.E)
+        if err != nil {
+            return nil, err
+        }
+        return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+    case "EC":
+        var curve elliptic.Curve
+        switch k.Crv {
+        case "P-256":
+            curve = elliptic.P256()
+        case "P-384":
+            curve = elliptic.P384()
+        case "P-521":
+            curve = elliptic.P521()
+        default:
+            return nil, fmt.Errorf("kurva %q tidak didukung", k.Crv)
+        }
+        x, err := base64.RawURLEncoding.DecodeString(k.X)
+        if err != nil {
+            return nil, err
+        }
+        y, err := base64.RawURLEncoding.DecodeString(k.Y)
+        if err != nil {
+            return nil, err
+        }
+        return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+    }
+    return nil, fmt.Errorf("tipe kunci %q tidak didukung", k.Kty)
+}
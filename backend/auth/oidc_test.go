@@ -0,0 +1,252 @@
+package auth
+
+import (
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/json"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/golang-jwt/jwt/v4"
+)
+
+const (
+    testClientID = "exam-app"
+    testKid      = "kunci-1"
+)
+
+// mockOIDCProvider adalah identity provider lokal yang menyajikan discovery,
+// JWKS dan token endpoint. Token endpoint memeriksa PKCE seperti provider
+// sungguhan lalu mengembalikan ID token dari klaim yang disiapkan test.
+type mockOIDCProvider struct {
+    t      *testing.T
+    server *httptest.Server
+    key    *rsa.PrivateKey
+
+    mu         sync.Mutex
+    challenges map[string]string // code -> code_challenge
+    claims     jwt.MapClaims     // klaim ID token berikutnya
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+    t.Helper()
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        t.Fatalf("gagal membuat kunci RSA: %v", err)
+    }
+    m := &mockOIDCProvider{t: t, key: key, challenges: map[string]string{}}
+    mux := http.NewServeMux()
+    mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+        json.NewEncoder(w).Encode(oidcDiscovery{
+            Issuer:                m.server.URL,
+            AuthorizationEndpoint: m.server.URL + "/authorize",
+            TokenEndpoint:         m.server.URL + "/token",
+            JWKSURI:               m.server.URL + "/jwks",
+        })
+    })
+    mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+        pub := m.key.PublicKey
+        json.NewEncoder(w).Encode(map[string]interface{}{
+            "keys": []jsonWebKey{{
+                Kty: "RSA",
+                Kid: testKid,
+                Use: "sig",
+                N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+                E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+            }},
+        })
+    })
+    mux.HandleFunc("/token", m.handleToken)
+    m.server = httptest.NewServer(mux)
+    t.Cleanup(m.server.Close)
+    return m
+}
+
+// authorize mensimulasikan login user di provider: code dikaitkan dengan
+// code_challenge dari AuthCodeURL
+func (m *mockOIDCProvider) authorize(t *testing.T, authURL string) string {
+    t.Helper()
+    u, err := url.Parse(authURL)
+    if err != nil {
+        t.Fatalf("URL login tidak valid: %v", err)
+    }
+    q := u.Query()
+    if q.Get("code_challenge_method") != "S256" {
+        t.Fatalf("code_challenge_method = %q, harus S256", q.Get("code_challenge_method"))
+    }
+    code := "code-" + q.Get("state")
+    m.mu.Lock()
+    m.challenges[code] = q.Get("code_challenge")
+    m.mu.Unlock()
+    return code
+}
+
+func (m *mockOIDCProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+    if err := r.ParseForm(); err != nil {
+        http.Error(w, "bad form", http.StatusBadRequest)
+        return
+    }
+    m.mu.Lock()
+    challenge, ok := m.challenges[r.PostForm.Get("code")]
+    delete(m.challenges, r.PostForm.Get("code"))
+    claims := m.claims
+    m.mu.Unlock()
+
+    sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+    if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+        w.WriteHeader(http.StatusBadRequest)
+        json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+        return
+    }
+    token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+    token.Header["kid"] = testKid
+    signed, err := token.SignedString(m.key)
+    if err != nil {
+        m.t.Errorf("gagal menandatangani ID token: %v", err)
+        w.WriteHeader(http.StatusInternalServerError)
+        return
+    }
+    json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+}
+
+// validClaims adalah klaim ID token yang lolos semua pemeriksaan
+func (m *mockOIDCProvider) validClaims(nonce string) jwt.MapClaims {
+    now := time.Now()
+    return jwt.MapClaims{
+        "iss":            m.server.URL,
+        "aud":            testClientID,
+        "sub":            "user-123",
+        "email":          "siswa@sekolah.id",
+        "email_verified": true,
+        "name":           "Siswa",
+        "nonce":          nonce,
+        "groups":         []string{"guru", "staf"},
+        "iat":            now.Unix(),
+        "exp":            now.Add(5 * time.Minute).Unix(),
+    }
+}
+
+// login menjalankan alur lengkap: URL login, code dari provider, lalu Exchange
+func login(t *testing.T, m *mockOIDCProvider, p *OIDCProvider, verifier, nonce string) (*OIDCIdentity, error) {
+    t.Helper()
+    realVerifier, challenge, err := NewPKCE()
+    if err != nil {
+        t.Fatalf("NewPKCE: %v", err)
+    }
+    authURL, err := p.AuthCodeURL("state-1", nonce, challenge)
+    if err != nil {
+        t.Fatalf("AuthCodeURL: %v", err)
+    }
+    code := m.authorize(t, authURL)
+    if verifier == "" {
+        verifier = realVerifier
+    }
+    return p.Exchange(code, verifier, nonce)
+}
+
+func newTestProvider(m *mockOIDCProvider, groupsClaim string) *OIDCProvider {
+    return NewOIDCProvider(OIDCConfig{
+        Issuer:      m.server.URL,
+        ClientID:    testClientID,
+        RedirectURL: "http://localhost:3000/api/auth/oidc/callback",
+        GroupsClaim: groupsClaim,
+    })
+}
+
+func TestOIDCExchange(t *testing.T) {
+    const nonce = "nonce-1"
+    tests := []struct {
+        name     string
+        verifier string // kosong berarti verifier yang benar
+        nonce    string // nonce yang diharapkan client
+        mutate   func(m *mockOIDCProvider, claims jwt.MapClaims)
+        wantErr  string
+    }{
+        {name: "valid"},
+        {name: "PKCE verifier salah", verifier: "verifier-lain", wantErr: "PKCE"},
+        {name: "nonce tidak cocok", nonce: "nonce-lain", wantErr: "nonce"},
+        {
+            name:    "audience salah",
+            mutate:  func(m *mockOIDCProvider, c jwt.MapClaims) { c["aud"] = "aplikasi-lain" },
+            wantErr: "audience",
+        },
+        {
+            name:    "issuer salah",
+            mutate:  func(m *mockOIDCProvider, c jwt.MapClaims) { c["iss"] = "https://idp.lain.example" },
+            wantErr: "issuer",
+        },
+        {
+            name: "ID token kedaluwarsa",
+            mutate: func(m *mockOIDCProvider, c jwt.MapClaims) {
+                c["iat"] = time.Now().Add(-time.Hour).Unix()
+                c["exp"] = time.Now().Add(-30 * time.Minute).Unix()
+            },
+            wantErr: "expired",
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            m := newMockOIDCProvider(t)
+            m.claims = m.validClaims(nonce)
+            if tt.mutate != nil {
+                tt.mutate(m, m.claims)
+            }
+            expected := nonce
+            if tt.nonce != "" {
+                expected = tt.nonce
+            }
+            id, err := login(t, m, newTestProvider(m, ""), tt.verifier, expected)
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("error = %v, harus memuat %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Exchange: %v", err)
+            }
+            if id.Subject != "user-123" || id.Email != "siswa@sekolah.id" || !id.EmailVerified {
+                t.Errorf("identitas tidak sesuai: %+v", id)
+            }
+        })
+    }
+}
+
+func TestOIDCGroupsClaim(t *testing.T) {
+    tests := []struct {
+        name        string
+        groupsClaim string
+        value       interface{}
+        want        []string
+    }{
+        {name: "array", groupsClaim: "groups", value: []string{"guru", "staf"}, want: []string{"guru", "staf"}},
+        {name: "string dipisah koma", groupsClaim: "groups", value: "guru, staf", want: []string{"guru", "staf"}},
+        {name: "klaim kustom", groupsClaim: "roles", value: []string{"admin-ujian"}, want: []string{"admin-ujian"}},
+        {name: "tanpa grup", groupsClaim: "groups", value: nil, want: nil},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            m := newMockOIDCProvider(t)
+            m.claims = m.validClaims("n")
+            delete(m.claims, "groups")
+            if tt.value != nil {
+                m.claims[tt.groupsClaim] = tt.value
+            }
+            id, err := login(t, m, newTestProvider(m, tt.groupsClaim), "", "n")
+            if err != nil {
+                t.Fatalf("Exchange: %v", err)
+            }
+            if strings.Join(id.Groups, "|") != strings.Join(tt.want, "|") {
+                t.Errorf("Groups = %v, harus %v", id.Groups, tt.want)
+            }
+        })
+    }
+}
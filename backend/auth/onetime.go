@@ -0,0 +1,63 @@
+package auth
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "time"
+
+    goredis "github.com/redis/go-redis/v9"
+)
+
+// ErrInvalidOneTimeToken dikembalikan jika token tidak dikenal, sudah
+// dipakai, atau sudah kedaluwarsa
+var ErrInvalidOneTimeToken = errors.New("token tidak valid atau sudah kedaluwarsa")
+
+// OneTimeTokens menerbitkan token acak sekali pakai di Redis. Yang disimpan
+// hanya hash token, dan pemakaian memakai GETDEL sehingga token tidak bisa
+// dipakai dua kali meskipun ada request bersamaan dari proses Prefork lain.
+type OneTimeTokens struct {
+    rdb    *goredis.Client
+    prefix string
+    ttl    time.Duration
+}
+
+// NewOneTimeTokens membuat penerbit token dengan prefix key Redis dan masa berlaku
+func NewOneTimeTokens(rdb *goredis.Client, prefix string, ttl time.Duration) *OneTimeTokens {
+    return &OneTimeTokens{rdb: rdb, prefix: prefix, ttl: ttl}
+}
+
+// Issue menyimpan payload dan mengembalikan token yang harus dikirim ke user
+func (t *OneTimeTokens) Issue(payload []byte) (string, error) {
+    token, err := randomString(32)
+    if err != nil {
+        return "", err
+    }
+    if err := t.rdb.Set(context.Background(), t.key(token), payload, t.ttl).Err(); err != nil {
+        return "", err
+    }
+    return token, nil
+}
+
+// Consume mengambil payload dan menghapus token secara atomik
+func (t *OneTimeTokens) Consume(token string) ([]byte, error) {
+    if token == "" {
+        return nil, ErrInvalidOneTimeToken
+    }
+    payload, err := t.rdb.GetDel(context.Background(), t.key(token)).Bytes()
+    if errors.Is(err, goredis.Nil) {
+        return nil, ErrInvalidOneTimeToken
+    }
+    return payload, err
+}
+
+// TTL mengembalikan masa berlaku token
+func (t *OneTimeTokens) TTL() time.Duration {
+    return t.ttl
+}
+
+func (t *OneTimeTokens) key(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return t.prefix + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+    "crypto/subtle"
+    "strings"
+
+    "golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher meng-hash dan memverifikasi password dengan bcrypt
+type PasswordHasher struct {
+    Cost int
+}
+
+// NewPasswordHasher membuat hasher dengan cost tertentu; cost di luar
+// rentang bcrypt diganti dengan bcrypt.DefaultCost
+func NewPasswordHasher(cost int) *PasswordHasher {
+    if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+        cost = bcrypt.DefaultCost
+    }
+    return &PasswordHasher{Cost: cost}
+}
+
+// Hash mengembalikan hash bcrypt dari password
+func (h *PasswordHasher) Hash(password string) (string, error) {
+    hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+    if err != nil {
+        return "", err
+    }
+    return string(hash), nil
+}
+
+// Verify membandingkan password dengan nilai tersimpan secara constant-time.
+// needsRehash bernilai true jika password cocok tetapi nilai tersimpan masih
+// plaintext lama atau memakai cost yang berbeda dari konfigurasi.
+func (h *PasswordHasher) Verify(stored, password string) (ok bool, needsRehash bool) {
+    // Akun tanpa password (misalnya dibuat lewat SSO) tidak bisa login dengan password
+    if stored == "" {
+        h.DummyVerify(password)
+        return false, false
+    }
+    if !isBcryptHash(stored) {
+        // Baris lama yang masih menyimpan plaintext
+        ok = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+        return ok, ok
+    }
+    if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)); err != nil {
+        return false, false
+    }
+    cost, err := bcrypt.Cost([]byte(stored))
+    return true, err != nil || cost != h.Cost
+}
+
+// DummyVerify menjalankan perbandingan bcrypt palsu agar waktu respons login
+// untuk email yang tidak terdaftar setara dengan email yang terdaftar
+func (h *PasswordHasher) DummyVerify(password string) {
+    bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+func isBcryptHash(s string) bool {
+    return len(s) == 60 && (strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$"))
+}
@@ -0,0 +1,84 @@
+package auth
+
+import "strings"
+
+// Role yang dikenal aplikasi
+const (
+    RoleAdmin       = "admin"
+    RoleTeacher     = "teacher"
+    RoleProctor     = "proctor"
+    RoleParticipant = "participant"
+)
+
+// roleLegacyUser adalah role lama untuk peserta sebelum ada model permission
+const roleLegacyUser = "user"
+
+// Permission adalah izin bernama yang dipakai untuk menjaga setiap rute
+type Permission string
+
+const (
+    PermUsersManage     Permission = "users:manage"
+    PermExamsView       Permission = "exams:view"
+    PermExamsManage     Permission = "exams:manage"
+    PermExamsAll        Permission = "exams:all" // tidak dibatasi ke ujian milik sendiri
+    PermGroupsManage    Permission = "groups:manage"
+    PermQuestionsManage Permission = "questions:manage"
+    PermResultsView     Permission = "results:view"
+    PermResultsExport   Permission = "results:export"
+    PermResultsGrade    Permission = "results:grade" // menilai jawaban esai secara manual
+    PermSessionsMonitor Permission = "sessions:monitor"
+    PermExamsTake       Permission = "exams:take"
+)
+
+// rolePermissions memetakan role ke izin yang dimilikinya
+var rolePermissions = map[string][]Permission{
+    RoleAdmin: {
+        PermUsersManage, PermExamsView, PermExamsManage, PermExamsAll, PermGroupsManage,
+        PermQuestionsManage, PermResultsView, PermResultsExport, PermResultsGrade, PermSessionsMonitor,
+    },
+    RoleTeacher: {
+        PermExamsView, PermExamsManage, PermGroupsManage, PermQuestionsManage,
+        PermResultsView, PermResultsExport, PermResultsGrade, PermSessionsMonitor,
+    },
+    RoleProctor: {
+        PermExamsView, PermExamsAll, PermSessionsMonitor,
+    },
+    RoleParticipant: {
+        PermExamsTake,
+    },
+}
+
+// Roles mengembalikan daftar role yang valid
+func Roles() []string {
+    return []string{RoleAdmin, RoleTeacher, RoleProctor, RoleParticipant}
+}
+
+// NormalizeRole merapikan penulisan role dan memetakan role lama "user" ke participant
+func NormalizeRole(role string) string {
+    role = strings.ToLower(strings.TrimSpace(role))
+    if role == roleLegacyUser {
+        return RoleParticipant
+    }
+    return role
+}
+
+// ValidRole memeriksa apakah role dikenal (setelah dinormalisasi)
+func ValidRole(role string) bool {
+    _, ok := rolePermissions[NormalizeRole(role)]
+    return ok
+}
+
+// HasPermission memeriksa apakah role memiliki izin tertentu
+func HasPermission(role string, perm Permission) bool {
+    for _, p := range rolePermissions[NormalizeRole(role)] {
+        if p == perm {
+            return true
+        }
+    }
+    return false
+}
+
+// PermissionsFor mengembalikan izin milik role, misalnya untuk ditampilkan di frontend
+func PermissionsFor(role string) []Permission {
+    return append([]Permission{}, rolePermissions[NormalizeRole(role)]...)
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "strconv"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+    "github.com/golang-jwt/jwt/v4"
+    goredis "github.com/redis/go-redis/v9"
+)
+
+// ErrInvalidRefreshToken dikembalikan jika refresh token tidak dikenal,
+// sudah dipakai, atau sudah dicabut
+var ErrInvalidRefreshToken = errors.New("refresh token tidak valid")
+
+// TokenPair adalah pasangan access token dan refresh token hasil login/refresh
+type TokenPair struct {
+    AccessToken  string `json:"token"`
+    RefreshToken string `json:"refresh_token"`
+    ExpiresIn    int    `json:"expires_in"` // masa berlaku access token dalam detik
+}
+
+// SessionManager menerbitkan access token berumur pendek dan refresh token
+// yang dirotasi setiap dipakai. Refresh token dan daftar pencabutan disimpan
+// di Redis lewat fiber.Storage; rdb dipakai langsung untuk operasi yang harus
+// atomik.
+type SessionManager struct {
+    storage    fiber.Storage
+    rdb        *goredis.Client
+    tokens     *TokenService
+    accessTTL  time.Duration
+    refreshTTL time.Duration
+}
+
+// Identity adalah data user yang dibawa di dalam access token
+type Identity struct {
+    UserID        uint
+    Role          string
+    EmailVerified bool
+    // MFA bernilai true jika login melewati verifikasi TOTP
+    MFA bool
+}
+
+type refreshRecord struct {
+    UserID   uint  `json:"user_id"`
+    IssuedAt int64 `json:"issued_at"` // detik, dari versi lama
+    // IssuedAtMs dipakai agar token yang terbit di detik yang sama dengan
+    // pencabutan semua sesi tetap bisa dibedakan
+    IssuedAtMs int64 `json:"issued_at_ms,omitempty"`
+    MFA        bool  `json:"mfa,omitempty"`
+}
+
+func (r refreshRecord) issuedAtMs() int64 {
+    if r.IssuedAtMs != 0 {
+        return r.IssuedAtMs
+    }
+    return r.IssuedAt * 1000
+}
+
+// NewSessionManager membuat SessionManager
+func NewSessionManager(storage fiber.Storage, rdb *goredis.Client, tokens *TokenService, accessTTL, refreshTTL time.Duration) *SessionManager {
+    return &SessionManager{storage: storage, rdb: rdb, tokens: tokens, accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+// Issue menerbitkan pasangan token baru untuk user
+func (m *SessionManager) Issue(id Identity) (*TokenPair, error) {
+    now := time.Now()
+    jti, err := randomString(16)
+    if err != nil {
+        return nil, err
+    }
+    access, err := m.tokens.Sign(jwt.MapClaims{
+        "typ":            TokenTypeAccess,
+        "user_id":        id.UserID,
+        "role":           id.Role,
+        "email_verified": id.EmailVerified,
+        "mfa":            id.MFA,
+        "jti":            jti,
+        "iat":            now.Unix(),
+        "iat_ms":         now.UnixMilli(),
+        "exp":            now.Add(m.accessTTL).Unix(),
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    refresh, err := randomString(32)
+    if err != nil {
+        return nil, err
+    }
+    record, _ := json.Marshal(refreshRecord{UserID: id.UserID, IssuedAt: now.Unix(), IssuedAtMs: now.UnixMilli(), MFA: id.MFA})
+    if err := m.storage.Set(refreshKey(refresh), record, m.refreshTTL); err != nil {
+        return nil, err
+    }
+    return &TokenPair{AccessToken: access, RefreshToken: refresh, ExpiresIn: int(m.accessTTL.Seconds())}, nil
+}
+
+// Consume memvalidasi dan menghapus refresh token (rotasi) secara atomik
+// dengan GETDEL, lalu mengembalikan pemiliknya dan apakah sesi itu sudah
+// lolos 2FA. Pemanggil menerbitkan pasangan token baru.
+func (m *SessionManager) Consume(refreshToken string) (uint, bool, error) {
+    if refreshToken == "" {
+        return 0, false, ErrInvalidRefreshToken
+    }
+    data, err := m.rdb.GetDel(context.Background(), refreshKey(refreshToken)).Bytes()
+    if errors.Is(err, goredis.Nil) {
+        return 0, false, ErrInvalidRefreshToken
+    }
+    if err != nil {
+        return 0, false, err
+    }
+    var record refreshRecord
+    if err := json.Unmarshal(data, &record); err != nil {
+        return 0, false, ErrInvalidRefreshToken
+    }
+    validAfter, err := m.validAfter(record.UserID)
+    if err != nil {
+        return 0, false, err
+    }
+    if record.issuedAtMs() < validAfter {
+        return 0, false, ErrInvalidRefreshToken
+    }
+    return record.UserID, record.MFA, nil
+}
+
+// RevokeRefresh menghapus satu refresh token
+func (m *SessionManager) RevokeRefresh(refreshToken string) error {
+    if refreshToken == "" {
+        return nil
+    }
+    return m.storage.Delete(refreshKey(refreshToken))
+}
+
+// RevokeAccess mencabut access token berdasarkan jti sampai token itu kedaluwarsa
+func (m *SessionManager) RevokeAccess(claims jwt.MapClaims) error {
+    jti, _ := claims["jti"].(string)
+    if jti == "" {
+        return nil
+    }
+    ttl := m.accessTTL
+    if exp, ok := claims["exp"].(float64); ok {
+        ttl = time.Until(time.Unix(int64(exp), 0))
+    }
+    if ttl <= 0 {
+        return nil
+    }
+    return m.storage.Set("revoked_jti:"+jti, []byte("1"), ttl)
+}
+
+// RevokeAllForUser membatalkan semua token (access dan refresh) milik user
+// yang diterbitkan sebelum saat ini (presisi milidetik)
+func (m *SessionManager) RevokeAllForUser(userID uint) error {
+    now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+    return m.storage.Set(validAfterKey(userID), []byte(now), m.refreshTTL)
+}
+
+// IsRevoked memeriksa apakah access token sudah dicabut, baik lewat jti
+// maupun lewat pencabutan semua sesi user
+func (m *SessionManager) IsRevoked(claims jwt.MapClaims) (bool, error) {
+    if jti, _ := claims["jti"].(string); jti != "" {
+        data, err := m.storage.Get("revoked_jti:" + jti)
+        if err != nil {
+            return false, err
+        }
+        if data != nil {
+            return true, nil
+        }
+    }
+    userID, _ := claims["user_id"].(float64)
+    validAfter, err := m.validAfter(uint(userID))
+    if err != nil {
+        return false, err
+    }
+    return issuedAtMs(claims) < validAfter, nil
+}
+
+// issuedAtMs membaca iat_ms, atau iat dalam detik untuk token versi lama
+func issuedAtMs(claims jwt.MapClaims) int64 {
+    if ms, ok := claims["iat_ms"].(float64); ok {
+        return int64(ms)
+    }
+    iat, _ := claims["iat"].(float64)
+    return int64(iat) * 1000
+}
+
+// validAfter mengembalikan batas waktu pencabutan dalam milidetik
+func (m *SessionManager) validAfter(userID uint) (int64, error) {
+    data, err := m.storage.Get(validAfterKey(userID))
+    if err != nil || data == nil {
+        return 0, err
+    }
+    value, err := strconv.ParseInt(string(data), 10, 64)
+    if err != nil {
+        return 0, err
+    }
+    // Nilai lama disimpan dalam detik
+    if value < 1e12 {
+        value *= 1000
+    }
+    return value, nil
+}
+
+func refreshKey(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return "refresh_token:" + hex.EncodeToString(sum[:])
+}
+
+func validAfterKey(userID uint) string {
+    return fmt.Sprintf("user_tokens_valid_after:%d", userID)
+}
+
+// NewTokenID membuat id acak untuk claim jti token lain
+func NewTokenID() (string, error) {
+    return randomString(16)
+}
+
+func randomString(n int) (string, error) {
+    b := make([]byte, n)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+    "crypto"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/golang-jwt/jwt/v4"
+    "online-exam-app-backend/utils"
+)
+
+// Nilai claim "typ" untuk membedakan kegunaan token yang ditandatangani
+// dengan kunci yang sama
+const (
+    TokenTypeAccess       = "access"
+    TokenTypeEmailVerify  = "email_verify"
+    TokenTypeMFAChallenge = "mfa_challenge"
+)
+
+// minHMACSecretLen adalah panjang minimum secret HS256 (256 bit)
+const minHMACSecretLen = 32
+
+// defaultJWTSecret adalah contoh secret lama di README yang tidak boleh dipakai
+const defaultJWTSecret = "your-secret-key"
+
+// validateHMACSecret menolak secret HS256 yang kosong, masih contoh bawaan,
+// atau terlalu pendek untuk dipakai menandatangani token
+func validateHMACSecret(id string, secret []byte) error {
+    switch {
+    case len(secret) == 0:
+        return fmt.Errorf("secret JWT %q kosong; isi JWT_SECRET", id)
+    case string(secret) == defaultJWTSecret:
+        return fmt.Errorf("secret JWT %q masih memakai nilai contoh; ganti JWT_SECRET", id)
+    case len(secret) < minHMACSecretLen:
+        return fmt.Errorf("secret JWT %q minimal %d byte", id, minHMACSecretLen)
+    }
+    return nil
+}
+
+// SigningKey adalah satu kunci JWT yang dikenali lewat key ID (kid).
+// SignKey boleh nil untuk kunci lama yang hanya dipakai memverifikasi.
+type SigningKey struct {
+    ID        string
+    Method    jwt.SigningMethod
+    SignKey   interface{}
+    VerifyKey interface{}
+}
+
+// TokenService menandatangani dan memverifikasi JWT dengan kunci aktif,
+// serta menerima token yang ditandatangani kunci lama selama masa rotasi
+type TokenService struct {
+    active *SigningKey
+    keys   map[string]*SigningKey
+}
+
+// NewTokenService membuat service dengan kunci aktif dan kunci verifikasi tambahan
+func NewTokenService(active *SigningKey, verifyOnly ...*SigningKey) (*TokenService, error) {
+    if active == nil || active.SignKey == nil {
+        return nil, errors.New("kunci aktif JWT harus bisa dipakai untuk menandatangani")
+    }
+    s := &TokenService{active: active, keys: map[string]*SigningKey{active.ID: active}}
+    for _, k := range verifyOnly {
+        if _, exists := s.keys[k.ID]; exists {
+            return nil, fmt.Errorf("kid JWT %q terdaftar lebih dari sekali", k.ID)
+        }
+        s.keys[k.ID] = k
+    }
+    return s, nil
+}
+
+// NewTokenServiceFromConfig membangun service dari JWT_ALGORITHM, JWT_SECRET,
+// JWT_PRIVATE_KEY_FILE, JWT_KEY_ID dan JWT_VERIFY_KEYS
+func NewTokenServiceFromConfig(c *utils.Config) (*TokenService, error) {
+    var active *SigningKey
+    var err error
+    if strings.ToUpper(c.JWTAlgorithm) == "HS256" {
+        if err := validateHMACSecret(c.JWTKeyID, []byte(c.JWTSecret)); err != nil {
+            return nil, err
+        }
+        active = NewHMACKey(c.JWTKeyID, []byte(c.JWTSecret))
+    } else {
+        active, err = LoadKeyFile(c.JWTKeyID, c.JWTAlgorithm, c.JWTPrivateKeyFile)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    // JWT_VERIFY_KEYS: daftar "kid:ALG:path" dipisah koma untuk kunci lama
+    var extra []*SigningKey
+    for _, entry := range strings.Split(c.JWTVerifyKeys, ",") {
+        entry = strings.TrimSpace(entry)
+        if entry == "" {
+            continue
+        }
+        parts := strings.SplitN(entry, ":", 3)
+        if len(parts) != 3 {
+            return nil, fmt.Errorf("format JWT_VERIFY_KEYS tidak valid: %q", entry)
+        }
+        k, err := LoadKeyFile(parts[0], parts[1], parts[2])
+        if err != nil {
+            return nil, err
+        }
+        extra = append(extra, k)
+    }
+    return NewTokenService(active, extra...)
+}
+
+// NewHMACKey membuat kunci HS256 dari secret
+func NewHMACKey(id string, secret []byte) *SigningKey {
+    return &SigningKey{ID: id, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}
+}
+
+// LoadKeyFile memuat kunci dari file. HS256 membaca secret mentah, RS256 dan
+// EdDSA membaca PEM private key (bisa menandatangani) atau public key (hanya verifikasi).
+func LoadKeyFile(id, alg, path string) (*SigningKey, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("gagal membaca kunci JWT %q: %w", id, err)
+    }
+    switch strings.ToUpper(alg) {
+    case "HS256":
+        secret := []byte(strings.TrimSpace(string(data)))
+        if err := validateHMACSecret(id, secret); err != nil {
+            return nil, err
+        }
+        return NewHMACKey(id, secret), nil
+    case "RS256":
+        if priv, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+            return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, SignKey: priv, VerifyKey: &priv.PublicKey}, nil
+        }
+        pub, err := jwt.ParseRSAPublicKeyFromPEM(data)
+        if err != nil {
+            return nil, fmt.Errorf("kunci RS256 %q tidak valid: %w", id, err)
+        }
+        return &SigningKey{ID: id, Method: jwt.SigningMethodRS256, VerifyKey: pub}, nil
+    case "EDDSA":
+        if priv, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+            return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, SignKey: priv, VerifyKey: priv.(crypto.Signer).Public()}, nil
+        }
+        pub, err := jwt.ParseEdPublicKeyFromPEM(data)
+        if err != nil {
+            return nil, fmt.Errorf("kunci EdDSA %q tidak valid: %w", id, err)
+        }
+        return &SigningKey{ID: id, Method: jwt.SigningMethodEdDSA, VerifyKey: pub}, nil
+    }
+    return nil, fmt.Errorf("algoritma JWT %q tidak didukung", alg)
+}
+
+// Sign menandatangani claims dengan kunci aktif dan menyertakan header kid
+func (s *TokenService) Sign(claims jwt.Claims) (string, error) {
+    token := jwt.NewWithClaims(s.active.Method, claims)
+    token.Header["kid"] = s.active.ID
+    return token.SignedString(s.active.SignKey)
+}
+
+// Parse memverifikasi token. Kunci dipilih berdasarkan kid, dan algoritma di
+// header harus sama dengan algoritma kunci tersebut. Token tanpa kid (dibuat
+// sebelum rotasi kunci) diverifikasi dengan kunci aktif.
+func (s *TokenService) Parse(tokenString string) (jwt.MapClaims, error) {
+    claims := jwt.MapClaims{}
+    token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+        key := s.active
+        if kid, ok := token.Header["kid"].(string); ok {
+            if key, ok = s.keys[kid]; !ok {
+                return nil, fmt.Errorf("kid %q tidak dikenal", kid)
+            }
+        }
+        if token.Method.Alg() != key.Method.Alg() {
+            return nil, fmt.Errorf("algoritma %q tidak diizinkan untuk kid %q", token.Method.Alg(), key.ID)
+        }
+        return key.VerifyKey, nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    if !token.Valid {
+        return nil, errors.New("token tidak valid")
+    }
+    return claims, nil
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha1"
+    "crypto/sha256"
+    "crypto/subtle"
+    "encoding/base32"
+    "encoding/binary"
+    "encoding/hex"
+    "fmt"
+    "net/url"
+    "strings"
+    "time"
+)
+
+// Parameter TOTP (RFC 6238) yang didukung aplikasi authenticator umum
+const (
+    totpDigits = 6
+    totpPeriod = 30 * time.Second
+    // Toleransi selisih jam perangkat: satu periode sebelum dan sesudah
+    totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret membuat secret acak 160 bit dalam base32
+func GenerateTOTPSecret() (string, error) {
+    b := make([]byte, 20)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPURI membuat URI otpauth:// untuk dijadikan QR code oleh frontend
+func TOTPURI(issuer, account, secret string) string {
+    v := url.Values{}
+    v.Set("secret", secret)
+    v.Set("issuer", issuer)
+    v.Set("digits", fmt.Sprint(totpDigits))
+    v.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+    label := url.PathEscape(issuer + ":" + account)
+    return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// ValidateTOTP memeriksa kode terhadap secret pada waktu now. Jika cocok,
+// step waktu yang dipakai dikembalikan agar pemanggil bisa menolak
+// pemakaian ulang kode yang sama.
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+    code = strings.TrimSpace(code)
+    if len(code) != totpDigits {
+        return 0, false
+    }
+    key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+    if err != nil {
+        return 0, false
+    }
+    step := now.Unix() / int64(totpPeriod.Seconds())
+    for i := int64(-totpSkew); i <= totpSkew; i++ {
+        if subtle.ConstantTimeCompare([]byte(hotp(key, step+i)), []byte(code)) == 1 {
+            return step + i, true
+        }
+    }
+    return 0, false
+}
+
+// hotp menghitung kode HOTP (RFC 4226) untuk counter tertentu
+func hotp(key []byte, counter int64) string {
+    var msg [8]byte
+    binary.BigEndian.PutUint64(msg[:], uint64(counter))
+    mac := hmac.New(sha1.New, key)
+    mac.Write(msg[:])
+    sum := mac.Sum(nil)
+    offset := sum[len(sum)-1] & 0x0f
+    value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+    return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// GenerateRecoveryCodes membuat n kode pemulihan sekali pakai. Nilai pertama
+// ditampilkan ke user, nilai kedua (hash) yang disimpan di database.
+func GenerateRecoveryCodes(n int) ([]string, []string, error) {
+    codes := make([]string, n)
+    hashes := make([]string, n)
+    for i := range codes {
+        b := make([]byte, 5)
+        if _, err := rand.Read(b); err != nil {
+            return nil, nil, err
+        }
+        raw := strings.ToLower(totpEncoding.EncodeToString(b))
+        codes[i] = raw[:4] + "-" + raw[4:]
+        hashes[i] = hashRecoveryCode(codes[i])
+    }
+    return codes, hashes, nil
+}
+
+// MatchRecoveryCode mencari kode pemulihan di daftar hash. Jika ketemu,
+// daftar hash tanpa kode tersebut dikembalikan (kode hanya berlaku sekali).
+func MatchRecoveryCode(hashes []string, code string) ([]string, bool) {
+    target := hashRecoveryCode(code)
+    for i, h := range hashes {
+        if subtle.ConstantTimeCompare([]byte(h), []byte(target)) == 1 {
+            remaining := append([]string{}, hashes[:i]...)
+            return append(remaining, hashes[i+1:]...), true
+        }
+    }
+    return hashes, false
+}
+
+// Kode pemulihan berentropi tinggi, jadi SHA-256 cukup tanpa bcrypt
+func hashRecoveryCode(code string) string {
+    normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+    sum := sha256.Sum256([]byte(normalized))
+    return hex.EncodeToString(sum[:])
+}
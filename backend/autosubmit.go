@@ -0,0 +1,134 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "time"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+    "online-exam-app-backend/drafts"
+    "online-exam-app-backend/grading"
+    "online-exam-app-backend/models"
+)
+
+// autoSubmitBatch membatasi jumlah attempt yang difinalisasi per transaksi
+const autoSubmitBatch = 100
+
+// startAutoSubmitWorker menjalankan finalisasi attempt yang lewat deadline
+// secara berkala. Dengan Prefork worker hanya dijalankan di proses master;
+// FOR UPDATE SKIP LOCKED tetap membuatnya aman jika beberapa instance
+// server berjalan bersamaan.
+func startAutoSubmitWorker(db *gorm.DB, interval, grace time.Duration) {
+    if interval <= 0 {
+        return
+    }
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for range ticker.C {
+            for {
+                n, err := finalizeExpiredAttempts(db, grace)
+                if err != nil {
+                    log.Printf("Gagal memfinalisasi attempt yang lewat deadline: %v", err)
+                    break
+                }
+                if n > 0 {
+                    log.Printf("%d attempt dikumpulkan otomatis setelah deadline", n)
+                }
+                if n < autoSubmitBatch {
+                    break
+                }
+            }
+        }
+    }()
+}
+
+// finalizeExpiredAttempts mengumpulkan attempt in_progress yang sudah lewat
+// deadline + grace dari draft terakhirnya dan mengembalikan jumlahnya
+func finalizeExpiredAttempts(db *gorm.DB, grace time.Duration) (int, error) {
+    var finalized []models.Attempt
+    err := db.Transaction(func(tx *gorm.DB) error {
+        var attempts []models.Attempt
+        err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+            Where("status = ? AND deadline < ?", models.AttemptInProgress, time.Now().Add(-grace)).
+            Order("deadline").
+            Limit(autoSubmitBatch).
+            Find(&attempts).Error
+        if err != nil {
+            return err
+        }
+        for i := range attempts {
+            if err := finalizeFromDrafts(tx, &attempts[i]); err != nil {
+                return err
+            }
+        }
+        finalized = attempts
+        return nil
+    })
+    if err != nil {
+        return 0, err
+    }
+    // Draft dan sesi di Redis baru dibersihkan setelah commit berhasil
+    for _, attempt := range finalized {
+        clearAttemptState(&attempt)
+    }
+    return len(finalized), nil
+}
+
+// finalizeFromDrafts mengumpulkan attempt memakai draft terakhir peserta
+// sebagai jawaban final, lalu menilainya. Pemanggil harus sudah mengunci
+// baris attempt di dalam transaksi tx.
+func finalizeFromDrafts(tx *gorm.DB, attempt *models.Attempt) error {
+    var questionIDs []uint
+    if err := tx.Model(&models.Question{}).Where("exam_id = ?", attempt.ExamID).Order("id").Pluck("id", &questionIDs).Error; err != nil {
+        return err
+    }
+    saved, err := loadDrafts(attempt.ID)
+    if err != nil {
+        return err
+    }
+    now := time.Now()
+    answers := make([]models.Answer, 0, len(saved))
+    for _, questionID := range questionIDs {
+        draft, ok := saved[questionID]
+        if !ok {
+            continue
+        }
+        answers = append(answers, models.Answer{
+            AttemptID:     attempt.ID,
+            ParticipantID: attempt.ParticipantID,
+            QuestionID:    questionID,
+            AnswerText:    draft,
+            SubmittedAt:   now,
+        })
+    }
+    if err := drafts.DeleteRows(tx, attempt.ID); err != nil {
+        return err
+    }
+    if len(answers) > 0 {
+        if err := tx.Create(&answers).Error; err != nil {
+            return err
+        }
+    }
+    attempt.Status = models.AttemptExpired
+    attempt.SubmittedAt = &now
+    attempt.AutoSubmitted = true
+    attempt.SavedCount = len(answers)
+    if err := tx.Save(attempt).Error; err != nil {
+        return err
+    }
+    _, err = grading.GradeAttempt(tx, attempt)
+    return err
+}
+
+// clearAttemptState menghapus draft dan sesi timer attempt yang sudah selesai
+func clearAttemptState(attempt *models.Attempt) {
+    if err := clearDrafts(attempt.ID); err != nil {
+        log.Printf("Gagal menghapus draft attempt %d: %v", attempt.ID, err)
+    }
+    key := fmt.Sprintf("exam_session:%d:%d", attempt.ParticipantID, attempt.ExamID)
+    if err := store.Storage.Delete(key); err != nil {
+        log.Printf("Gagal menghapus %s: %v", key, err)
+    }
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+    "time"
+
+    "gorm.io/gorm"
+    "online-exam-app-backend/models"
+)
+
+// draftExpiry menentukan kapan draft attempt dihapus Redis: setelah deadline,
+// toleransi submit dan DRAFT_RETENTION agar worker auto-submit masih sempat
+// membacanya
+func draftExpiry(attempt *models.Attempt) time.Time {
+    return attempt.Deadline.Add(config.DeadlineGrace + config.DraftRetention)
+}
+
+// saveDraft menyimpan draft jawaban satu soal untuk attempt
+func saveDraft(attempt *models.Attempt, questionID uint, answerText string) error {
+    return draftStore.Save(attempt, questionID, answerText, draftExpiry(attempt))
+}
+
+// loadDrafts mengambil semua draft attempt, dipetakan per question_id
+func loadDrafts(attemptID uint) (map[uint]string, error) {
+    return draftStore.Load(attemptID)
+}
+
+// clearDrafts menghapus semua draft attempt
+func clearDrafts(attemptID uint) error {
+    return draftStore.Clear(attemptID)
+}
+
+// runningAttempt mencari attempt in_progress terbaru peserta untuk ujian.
+// Attempt dengan ID 0 berarti tidak ada attempt yang berjalan.
+func runningAttempt(db *gorm.DB, examID, userID uint) (models.Attempt, error) {
+    var attempt models.Attempt
+    err := db.Where("exam_id = ? AND participant_id = ? AND status = ?", examID, userID, models.AttemptInProgress).
+        Order("id DESC").Limit(1).Find(&attempt).Error
+    return attempt, err
+}
@@ -0,0 +1,296 @@
+package drafts
+
+import (
+    "context"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    goredis "github.com/redis/go-redis/v9"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+    "online-exam-app-backend/models"
+    "online-exam-app-backend/utils"
+)
+
+var ctx = context.Background()
+
+// Store menyimpan draft jawaban per attempt. Implementasi dipilih lewat
+// DRAFT_STORAGE: redis, postgres, atau hybrid (default).
+type Store interface {
+    // Save menyimpan draft satu soal; expiresAt hanya dipakai penyimpanan Redis
+    Save(attempt *models.Attempt, questionID uint, answerText string, expiresAt time.Time) error
+    // Load mengambil semua draft attempt, dipetakan per question_id
+    Load(attemptID uint) (map[uint]string, error)
+    // Clear menghapus semua draft attempt
+    Clear(attemptID uint) error
+}
+
+// New memilih implementasi Store dari konfigurasi
+func New(c *utils.Config, rdb *goredis.Client, db *gorm.DB) (Store, error) {
+    switch strings.ToLower(c.DraftStorage) {
+    case "redis":
+        return &RedisStore{rdb: rdb}, nil
+    case "postgres":
+        return &PostgresStore{db: db}, nil
+    case "", "hybrid":
+        return &HybridStore{RedisStore: RedisStore{rdb: rdb}, pg: PostgresStore{db: db}}, nil
+    }
+    return nil, fmt.Errorf("DRAFT_STORAGE %q tidak dikenal", c.DraftStorage)
+}
+
+// DeleteRows menghapus baris draft attempt di tabel answers. Dipanggil di
+// dalam transaksi submit sebelum jawaban final ditulis, karena draft dan
+// jawaban final memakai unique index (attempt_id, question_id) yang sama.
+func DeleteRows(tx *gorm.DB, attemptID uint) error {
+    return tx.Where("attempt_id = ? AND is_draft = ?", attemptID, true).Delete(&models.Answer{}).Error
+}
+
+// RedisStore menyimpan draft di hash Redis draft_answers:<attempt_id>.
+// Cepat, tetapi draft hilang jika Redis restart tanpa persistence.
+type RedisStore struct {
+    rdb *goredis.Client
+}
+
+func redisKey(attemptID uint) string {
+    return fmt.Sprintf("draft_answers:%d", attemptID)
+}
+
+func (s *RedisStore) Save(attempt *models.Attempt, questionID uint, answerText string, expiresAt time.Time) error {
+    key := redisKey(attempt.ID)
+    pipe := s.rdb.TxPipeline()
+    pipe.HSet(ctx, key, strconv.FormatUint(uint64(questionID), 10), answerText)
+    pipe.ExpireAt(ctx, key, expiresAt)
+    _, err := pipe.Exec(ctx)
+    return err
+}
+
+func (s *RedisStore) Load(attemptID uint) (map[uint]string, error) {
+    fields, err := s.rdb.HGetAll(ctx, redisKey(attemptID)).Result()
+    if err != nil {
+        return nil, err
+    }
+    drafts := make(map[uint]string, len(fields))
+    for field, value := range fields {
+        questionID, err := strconv.ParseUint(field, 10, 64)
+        if err != nil {
+            continue
+        }
+        drafts[uint(questionID)] = value
+    }
+    return drafts, nil
+}
+
+func (s *RedisStore) Clear(attemptID uint) error {
+    return s.rdb.Del(ctx, redisKey(attemptID)).Err()
+}
+
+// PostgresStore menyimpan draft sebagai baris answers dengan is_draft = true
+type PostgresStore struct {
+    db *gorm.DB
+}
+
+func (s *PostgresStore) Save(attempt *models.Attempt, questionID uint, answerText string, _ time.Time) error {
+    return upsertDrafts(s.db, []models.Answer{draftRow(attempt.ID, attempt.ParticipantID, questionID, answerText)})
+}
+
+func (s *PostgresStore) Load(attemptID uint) (map[uint]string, error) {
+    var rows []models.Answer
+    if err := s.db.Where("attempt_id = ? AND is_draft = ?", attemptID, true).Find(&rows).Error; err != nil {
+        return nil, err
+    }
+    drafts := make(map[uint]string, len(rows))
+    for _, row := range rows {
+        drafts[row.QuestionID] = row.AnswerText
+    }
+    return drafts, nil
+}
+
+func (s *PostgresStore) Clear(attemptID uint) error {
+    return DeleteRows(s.db, attemptID)
+}
+
+func draftRow(attemptID, participantID, questionID uint, answerText string) models.Answer {
+    return models.Answer{
+        AttemptID:     attemptID,
+        ParticipantID: participantID,
+        QuestionID:    questionID,
+        AnswerText:    answerText,
+        SubmittedAt:   time.Now(),
+        IsDraft:       true,
+    }
+}
+
+// upsertDrafts menulis draft; baris yang sudah menjadi jawaban final tidak
+// pernah ditimpa
+func upsertDrafts(db *gorm.DB, rows []models.Answer) error {
+    if len(rows) == 0 {
+        return nil
+    }
+    return db.Clauses(clause.OnConflict{
+        Columns:   []clause.Column{{Name: "attempt_id"}, {Name: "question_id"}},
+        DoUpdates: clause.AssignmentColumns([]string{"answer_text", "submitted_at"}),
+        Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "answers.is_draft"}}},
+    }).Create(&rows).Error
+}
+
+// HybridStore menulis draft ke Redis lalu menyalinnya ke Postgres secara
+// berkala (write-behind). Soal yang berubah dicatat di set Redis draft_dirty
+// yang dibaca saat flush.
+type HybridStore struct {
+    RedisStore
+    pg PostgresStore
+}
+
+// dirtyKey adalah set Redis berisi "<attempt_id>:<question_id>" yang belum di-flush
+const dirtyKey = "draft_dirty"
+
+// flushBatch membatasi jumlah draft yang ditulis per transaksi flush
+const flushBatch = 500
+
+func (s *HybridStore) Save(attempt *models.Attempt, questionID uint, answerText string, expiresAt time.Time) error {
+    key := redisKey(attempt.ID)
+    pipe := s.rdb.TxPipeline()
+    pipe.HSet(ctx, key, strconv.FormatUint(uint64(questionID), 10), answerText)
+    pipe.ExpireAt(ctx, key, expiresAt)
+    pipe.SAdd(ctx, dirtyKey, fmt.Sprintf("%d:%d", attempt.ID, questionID))
+    _, err := pipe.Exec(ctx)
+    return err
+}
+
+// Load menggabungkan draft di Postgres dengan draft di Redis. Redis selalu
+// lebih baru, sedangkan Postgres memulihkan draft yang hilang dari Redis.
+func (s *HybridStore) Load(attemptID uint) (map[uint]string, error) {
+    drafts, err := s.pg.Load(attemptID)
+    if err != nil {
+        return nil, err
+    }
+    fresh, err := s.RedisStore.Load(attemptID)
+    if err != nil {
+        return nil, err
+    }
+    for questionID, answerText := range fresh {
+        drafts[questionID] = answerText
+    }
+    return drafts, nil
+}
+
+func (s *HybridStore) Clear(attemptID uint) error {
+    if err := s.RedisStore.Clear(attemptID); err != nil {
+        return err
+    }
+    return s.pg.Clear(attemptID)
+}
+
+// Flush menyalin draft yang berubah dari Redis ke Postgres dan mengembalikan
+// jumlah draft yang ditulis
+func (s *HybridStore) Flush() (int, error) {
+    members, err := s.rdb.SPopN(ctx, dirtyKey, flushBatch).Result()
+    if err != nil || len(members) == 0 {
+        return 0, err
+    }
+
+    byAttempt := make(map[uint][]uint)
+    for _, member := range members {
+        var attemptID, questionID uint
+        if _, err := fmt.Sscanf(member, "%d:%d", &attemptID, &questionID); err != nil {
+            continue
+        }
+        byAttempt[attemptID] = append(byAttempt[attemptID], questionID)
+    }
+
+    var rows []models.Answer
+    for attemptID, questionIDs := range byAttempt {
+        fields := make([]string, len(questionIDs))
+        for i, questionID := range questionIDs {
+            fields[i] = strconv.FormatUint(uint64(questionID), 10)
+        }
+        values, err := s.rdb.HMGet(ctx, redisKey(attemptID), fields...).Result()
+        if err != nil {
+            s.requeue(members)
+            return 0, err
+        }
+        for i, value := range values {
+            // Draft sudah kedaluwarsa atau dihapus setelah submit
+            if answerText, ok := value.(string); ok {
+                rows = append(rows, draftRow(attemptID, 0, questionIDs[i], answerText))
+            }
+        }
+    }
+    if len(rows) == 0 {
+        return 0, nil
+    }
+
+    var written int
+    err = s.pg.db.Transaction(func(tx *gorm.DB) error {
+        // Kunci attempt agar submit yang berjalan bersamaan menunggu flush
+        // selesai, lalu hanya tulis draft untuk attempt yang masih berjalan
+        var running []models.Attempt
+        err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+            Select("id", "participant_id").
+            Where("id IN ? AND status = ?", mapKeys(byAttempt), models.AttemptInProgress).
+            Find(&running).Error
+        if err != nil {
+            return err
+        }
+        participants := make(map[uint]uint, len(running))
+        for _, a := range running {
+            participants[a.ID] = a.ParticipantID
+        }
+        pending := rows[:0]
+        for _, row := range rows {
+            if participantID, ok := participants[row.AttemptID]; ok {
+                row.ParticipantID = participantID
+                pending = append(pending, row)
+            }
+        }
+        written = len(pending)
+        return upsertDrafts(tx, pending)
+    })
+    if err != nil {
+        s.requeue(members)
+        return 0, err
+    }
+    return written, nil
+}
+
+// requeue mengembalikan draft ke set dirty agar dicoba lagi pada flush berikutnya
+func (s *HybridStore) requeue(members []string) {
+    args := make([]interface{}, len(members))
+    for i, m := range members {
+        args[i] = m
+    }
+    s.rdb.SAdd(ctx, dirtyKey, args...)
+}
+
+// StartWriteBehind menjalankan Flush secara berkala di goroutine terpisah
+func (s *HybridStore) StartWriteBehind(interval time.Duration, logf func(format string, args ...interface{})) {
+    if interval <= 0 {
+        return
+    }
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for range ticker.C {
+            for {
+                n, err := s.Flush()
+                if err != nil {
+                    logf("Gagal menyalin draft ke Postgres: %v", err)
+                    break
+                }
+                if n < flushBatch {
+                    break
+                }
+            }
+        }
+    }()
+}
+
+func mapKeys(m map[uint][]uint) []uint {
+    keys := make([]uint, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    return keys
+}
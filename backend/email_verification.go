@@ -0,0 +1,155 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "net/url"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+    "github.com/golang-jwt/jwt/v4"
+    "gorm.io/gorm"
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/mailer"
+)
+
+// sendVerificationEmail mengirim tautan verifikasi bertanda tangan (JWT
+// dengan typ email_verify) ke alamat email user di background
+func sendVerificationEmail(user User) {
+    token, err := tokens.Sign(jwt.MapClaims{
+        "typ":     auth.TokenTypeEmailVerify,
+        "user_id": user.ID,
+        "email":   user.Email,
+        "exp":     time.Now().Add(config.EmailVerifyTTL).Unix(),
+    })
+    if err != nil {
+        log.Printf("Gagal membuat token verifikasi untuk user %d: %v", user.ID, err)
+        return
+    }
+    link := fmt.Sprintf("%s/verify-email?token=%s", config.AppURL, url.QueryEscape(token))
+    msg := mailer.Message{
+        To:      user.Email,
+        Subject: "Verifikasi email Ujian Online",
+        Body: fmt.Sprintf("Halo,\n\nSilakan verifikasi email Anda sebelum mengikuti ujian dengan membuka tautan berikut "+
+            "(berlaku %d jam):\n\n%s\n", int(config.EmailVerifyTTL.Hours()), link),
+    }
+    go func() {
+        if err := mail.Send(msg); err != nil {
+            log.Printf("Gagal mengirim email verifikasi ke user %d: %v", user.ID, err)
+        }
+    }()
+}
+
+// registerEmailVerificationRoutes mendaftarkan endpoint verifikasi untuk user
+func registerEmailVerificationRoutes(app fiber.Router, db *gorm.DB) {
+    // Verify email dari tautan di email
+    app.Post("/api/email/verify", func(c *fiber.Ctx) error {
+        var req struct {
+            Token string `json:"token"`
+        }
+        if err := c.BodyParser(&req); err != nil || req.Token == "" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Token verifikasi wajib diisi",
+            })
+        }
+        claims, err := tokens.Parse(req.Token)
+        if typ, _ := claims["typ"].(string); err != nil || typ != auth.TokenTypeEmailVerify {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Tautan verifikasi tidak valid atau sudah kedaluwarsa",
+            })
+        }
+        userID, _ := claims["user_id"].(float64)
+        email, _ := claims["email"].(string)
+        var user User
+        // Tautan hanya berlaku untuk alamat email yang sama saat tautan dibuat
+        if err := db.First(&user, uint(userID)).Error; err != nil || user.Email != email {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Tautan verifikasi tidak valid atau sudah kedaluwarsa",
+            })
+        }
+        if !user.EmailVerified {
+            if err := db.Model(&user).Update("email_verified", true).Error; err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Gagal memverifikasi email",
+                })
+            }
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Email berhasil diverifikasi",
+        })
+    })
+
+    // Resend verification email untuk user yang sedang login
+    app.Post("/api/email/resend", authMiddleware, func(c *fiber.Ctx) error {
+        userID := c.Locals("user_id").(float64)
+        var user User
+        if err := db.First(&user, uint(userID)).Error; err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User tidak ditemukan",
+            })
+        }
+        if user.EmailVerified {
+            return c.JSON(fiber.Map{
+                "success": true,
+                "message": "Email sudah terverifikasi",
+            })
+        }
+        sendVerificationEmail(user)
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Email verifikasi telah dikirim ulang",
+        })
+    })
+}
+
+// registerAdminVerificationRoutes mendaftarkan aksi verifikasi di /api/admin/users
+func registerAdminVerificationRoutes(admin fiber.Router, db *gorm.DB) {
+    // Resend verification email
+    admin.Post("/users/:id/resend-verification", requirePermission(auth.PermUsersManage), func(c *fiber.Ctx) error {
+        var user User
+        if err := db.First(&user, c.Params("id")).Error; err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User tidak ditemukan",
+            })
+        }
+        if user.EmailVerified {
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "Email user sudah terverifikasi",
+            })
+        }
+        sendVerificationEmail(user)
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Email verifikasi telah dikirim ulang",
+        })
+    })
+
+    // Force verification tanpa menunggu user membuka tautan
+    admin.Post("/users/:id/verify", requirePermission(auth.PermUsersManage), func(c *fiber.Ctx) error {
+        var user User
+        if err := db.First(&user, c.Params("id")).Error; err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User tidak ditemukan",
+            })
+        }
+        if err := db.Model(&user).Update("email_verified", true).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal memverifikasi user",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "User berhasil diverifikasi",
+        })
+    })
+}
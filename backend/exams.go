@@ -0,0 +1,553 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/drafts"
+    "online-exam-app-backend/grading"
+    "online-exam-app-backend/models"
+)
+
+// registerExamRoutes mendaftarkan endpoint pengerjaan ujian untuk peserta
+func registerExamRoutes(app fiber.Router, db *gorm.DB) {
+    draftLimit := newRateLimiter("draft_user", config.DraftRateLimit, config.DraftRateWindow, limitByUser)
+
+    // List all exams
+    app.Get("/api/exams", authMiddleware, verifiedMiddleware, requirePermission(auth.PermExamsTake), func(c *fiber.Ctx) error {
+        var exams []models.Exam
+        // Hanya ujian dari group tempat peserta terdaftar
+        err := enrolledExams(db, currentUserID(c)).
+            Select("id", "title", "duration", "opens_at", "closes_at", "max_attempts", "grading_policy").
+            Order("opens_at NULLS FIRST, id").
+            Find(&exams).Error
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil data ujian",
+            })
+        }
+        now := time.Now()
+        result := make([]fiber.Map, 0, len(exams))
+        for _, exam := range exams {
+            result = append(result, fiber.Map{
+                "id":        exam.ID,
+                "title":     exam.Title,
+                "duration":  exam.Duration,
+                "opens_at":  exam.OpensAt,
+                "closes_at": exam.ClosesAt,
+                "status":    exam.StatusAt(now),
+                "max_attempts":   exam.MaxAttempts,
+                "grading_policy": exam.GradingPolicy,
+            })
+        }
+        return c.JSON(result)
+    })
+
+    // Get questions for an exam
+    app.Get("/api/exam/:id/questions", authMiddleware, verifiedMiddleware, requirePermission(auth.PermExamsTake), func(c *fiber.Ctx) error {
+        var exam models.Exam
+        if err := enrolledExams(db, currentUserID(c)).First(&exam, c.Params("id")).Error; err != nil {
+            return examNotFound(c)
+        }
+        // Soal hanya terlihat selama ujian dibuka, atau selama peserta masih
+        // memiliki attempt berjalan (mis. deadline-nya melewati closes_at)
+        running, err := runningAttempt(db, exam.ID, currentUserID(c))
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil soal",
+            })
+        }
+        if running.ID == 0 {
+            switch exam.StatusAt(time.Now()) {
+            case models.ExamUpcoming:
+                return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Ujian belum dibuka",
+                    "opens_at": exam.OpensAt,
+                })
+            case models.ExamClosed:
+                return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Ujian sudah ditutup",
+                    "closes_at": exam.ClosesAt,
+                })
+            }
+        }
+        // Hanya kolom yang boleh dilihat peserta yang diambil
+        var dbQuestions []models.Question
+        if err := db.Select("id", "question_text", "type", "options", "prompts").Where("exam_id = ?", exam.ID).Order("id").Find(&dbQuestions).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil soal",
+            })
+        }
+        questions := make([]ParticipantQuestion, 0, len(dbQuestions))
+        for _, q := range dbQuestions {
+            questions = append(questions, toParticipantQuestion(q))
+        }
+        return c.JSON(questions)
+    })
+
+    // Session handling endpoint
+    app.Post("/api/exam/:id/start", authMiddleware, verifiedMiddleware, requirePermission(auth.PermExamsTake), func(c *fiber.Ctx) error {
+        userID := c.Locals("user_id").(float64)
+
+        var exam models.Exam
+        if err := enrolledExams(db, uint(userID)).First(&exam, c.Params("id")).Error; err != nil {
+            return examNotFound(c)
+        }
+        examID := exam.ID
+
+        // Durasi diambil dari data ujian, ditambah penyesuaian per peserta jika ada
+        var override models.ExamOverride
+        if err := db.Where("exam_id = ? AND user_id = ?", exam.ID, uint(userID)).Limit(1).Find(&override).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal memulai ujian",
+            })
+        }
+        duration := exam.DurationFor(&override)
+        if duration <= 0 {
+            return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+                "success": false,
+                "message": "Durasi ujian belum diatur",
+            })
+        }
+
+        // Ujian hanya bisa dimulai di dalam jendela opens_at..closes_at
+        now := time.Now()
+        switch exam.StatusAt(now) {
+        case models.ExamUpcoming:
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Ujian belum dibuka",
+                "opens_at": exam.OpensAt,
+            })
+        case models.ExamClosed:
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Ujian sudah ditutup",
+                "closes_at": exam.ClosesAt,
+            })
+        }
+
+        // Attempt yang masih berjalan dilanjutkan agar timer tidak bisa diulang.
+        // Attempt baru hanya dibuat jika batas max_attempts belum tercapai, dan
+        // deadline-nya dipotong di closes_at sehingga sisa durasi bisa lebih pendek.
+        var attempt models.Attempt
+        var finalized *models.Attempt
+        resumed := false
+        err := db.Transaction(func(tx *gorm.DB) error {
+            // Kunci baris user agar dua request start bersamaan tidak membuat dua attempt
+            err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&User{}, uint(userID)).Error
+            if err != nil {
+                return err
+            }
+            // Baris attempt dikunci agar tidak difinalisasi bersamaan dengan
+            // worker auto-submit; attempt yang sudah dikumpulkan worker tidak
+            // lolos filter status setelah kunci didapat
+            running, err := runningAttempt(tx.Clauses(clause.Locking{Strength: "UPDATE"}), examID, uint(userID))
+            if err != nil {
+                return err
+            }
+            if running.ID != 0 && running.Status == models.AttemptInProgress {
+                // Selama masa toleransi attempt masih bisa dikumpulkan peserta
+                if now.Before(running.Deadline.Add(config.DeadlineGrace)) {
+                    attempt = running
+                    resumed = true
+                    return nil
+                }
+                // Attempt lama yang lewat deadline + toleransi dikumpulkan dari draft-nya
+                if err := finalizeFromDrafts(tx, &running); err != nil {
+                    return err
+                }
+                finalized = &running
+            }
+
+            if exam.MaxAttempts > 0 {
+                var used int64
+                err := tx.Model(&models.Attempt{}).
+                    Where("exam_id = ? AND participant_id = ?", examID, uint(userID)).
+                    Count(&used).Error
+                if err != nil {
+                    return err
+                }
+                if used >= int64(exam.MaxAttempts) {
+                    return errAttemptLimit
+                }
+            }
+
+            attempt = models.Attempt{
+                ExamID:        examID,
+                ParticipantID: uint(userID),
+                Status:        models.AttemptInProgress,
+                StartedAt:     now,
+                Deadline:      exam.DeadlineFor(now, duration),
+            }
+            return tx.Create(&attempt).Error
+        })
+        if errors.Is(err, errAttemptLimit) {
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Batas percobaan ujian sudah tercapai",
+                "max_attempts": exam.MaxAttempts,
+            })
+        }
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal memulai ujian",
+            })
+        }
+        if finalized != nil {
+            clearAttemptState(finalized)
+        }
+
+        // Create exam session
+        session := ExamSession{
+            UserID:    uint(userID),
+            ExamID:    examID,
+            AttemptID: attempt.ID,
+            StartTime: attempt.StartedAt,
+            Duration:  int(attempt.Deadline.Sub(attempt.StartedAt).Seconds()),
+        }
+        
+        // Store in Redis
+        sessionKey := fmt.Sprintf("exam_session:%d:%d", uint(userID), examID)
+        sessionData, _ := json.Marshal(session)
+        err = store.Storage.Set(sessionKey, sessionData, time.Until(attempt.Deadline.Add(config.DeadlineGrace)))
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal memulai ujian",
+            })
+        }
+        
+        remaining := int(time.Until(attempt.Deadline).Seconds())
+        if remaining < 0 {
+            remaining = 0
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "attempt_id": attempt.ID,
+            "start_time": session.StartTime,
+            "duration": session.Duration,
+            "remaining_time": remaining,
+            "resumed": resumed,
+        })
+    })
+
+    // Auto-save answer endpoint
+    app.Post("/api/answers/draft", authMiddleware, verifiedMiddleware, requirePermission(auth.PermExamsTake), draftLimit, func(c *fiber.Ctx) error {
+        var answer struct {
+            QuestionID uint   `json:"question_id"`
+            AnswerText string `json:"answer_text"`
+        }
+        
+        if err := c.BodyParser(&answer); err != nil || answer.QuestionID == 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        
+        userID := c.Locals("user_id").(float64)
+
+        // Draft disimpan per attempt yang sedang berjalan untuk ujian soal ini
+        var question models.Question
+        if err := db.Select("id", "exam_id").First(&question, answer.QuestionID).Error; err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Soal tidak ditemukan",
+            })
+        }
+        attempt, err := runningAttempt(db, question.ExamID, uint(userID))
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menyimpan jawaban sementara",
+            })
+        }
+        if attempt.ID == 0 {
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "Ujian belum dimulai",
+            })
+        }
+        if time.Now().After(attempt.Deadline.Add(config.DeadlineGrace)) {
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "Waktu ujian sudah habis",
+            })
+        }
+
+        if err := saveDraft(&attempt, question.ID, answer.AnswerText); err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menyimpan jawaban sementara",
+            })
+        }
+        
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Jawaban tersimpan sementara",
+        })
+    })
+
+    // Submit final answers: semua jawaban ditulis dalam satu transaksi
+    app.Post("/api/answers/submit", authMiddleware, verifiedMiddleware, requirePermission(auth.PermExamsTake), func(c *fiber.Ctx) error {
+        var req struct {
+            ExamID  uint `json:"exam_id"`
+            Answers []struct {
+                QuestionID uint   `json:"question_id"`
+                AnswerText string `json:"answer_text"`
+            } `json:"answers"`
+        }
+        if err := c.BodyParser(&req); err != nil || req.ExamID == 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        
+        userID := c.Locals("user_id").(float64)
+
+        var exam models.Exam
+        if err := db.First(&exam, req.ExamID).Error; err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Ujian tidak ditemukan",
+            })
+        }
+
+        // Tolak jawaban untuk soal di luar ujian ini
+        var examQuestionIDs []uint
+        if err := db.Model(&models.Question{}).Where("exam_id = ?", exam.ID).Pluck("id", &examQuestionIDs).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil soal",
+            })
+        }
+        inExam := make(map[uint]bool, len(examQuestionIDs))
+        for _, id := range examQuestionIDs {
+            inExam[id] = true
+        }
+        // Jika satu soal dikirim lebih dari sekali, jawaban terakhir yang dipakai
+        latest := make(map[uint]string, len(req.Answers))
+        order := make([]uint, 0, len(req.Answers))
+        for _, a := range req.Answers {
+            if !inExam[a.QuestionID] {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": fmt.Sprintf("Soal %d bukan bagian dari ujian ini", a.QuestionID),
+                })
+            }
+            if _, seen := latest[a.QuestionID]; !seen {
+                order = append(order, a.QuestionID)
+            }
+            latest[a.QuestionID] = a.AnswerText
+        }
+
+        now := time.Now()
+        answers := make([]models.Answer, 0, len(order))
+        for _, questionID := range order {
+            answers = append(answers, models.Answer{
+                ParticipantID: uint(userID),
+                QuestionID:    questionID,
+                AnswerText:    latest[questionID],
+                SubmittedAt:   now,
+                IsDraft:       false,
+            })
+        }
+
+        // Idempotency-Key membuat percobaan ulang dari klien aman: attempt yang
+        // sudah dikumpulkan dengan kunci yang sama cukup dijawab ulang
+        idempotencyKey := c.Get("Idempotency-Key")
+        var attempt models.Attempt
+        var replay bool
+        err := db.Transaction(func(tx *gorm.DB) error {
+            err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+                Where("exam_id = ? AND participant_id = ?", exam.ID, uint(userID)).
+                Order("id DESC").
+                First(&attempt).Error
+            if err != nil {
+                return errNoAttempt
+            }
+            switch attempt.Status {
+            case models.AttemptSubmitted:
+                if idempotencyKey != "" && idempotencyKey == attempt.IdempotencyKey {
+                    replay = true
+                    return nil
+                }
+                return errAttemptSubmitted
+            case models.AttemptExpired:
+                return errAttemptExpired
+            }
+            // Deadline ditegakkan di server; DEADLINE_GRACE memberi toleransi
+            // untuk latensi jaringan saat klien submit tepat di akhir waktu
+            if now.After(attempt.Deadline.Add(config.DeadlineGrace)) {
+                return errDeadlinePassed
+            }
+            if exam.StatusAt(now.Add(-config.DeadlineGrace)) == models.ExamClosed {
+                return errExamClosed
+            }
+
+            for i := range answers {
+                answers[i].AttemptID = attempt.ID
+            }
+            // Draft di Postgres diganti jawaban final
+            if err := drafts.DeleteRows(tx, attempt.ID); err != nil {
+                return err
+            }
+            if len(answers) > 0 {
+                if err := tx.Create(&answers).Error; err != nil {
+                    return err
+                }
+            }
+            submittedAt := now
+            attempt.Status = models.AttemptSubmitted
+            attempt.SubmittedAt = &submittedAt
+            attempt.IdempotencyKey = idempotencyKey
+            attempt.SavedCount = len(answers)
+            if err := tx.Save(&attempt).Error; err != nil {
+                return err
+            }
+            // Skor dihitung di server dalam transaksi yang sama
+            _, err = grading.GradeAttempt(tx, &attempt)
+            return err
+        })
+        switch {
+        case errors.Is(err, errNoAttempt):
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "Ujian belum dimulai",
+            })
+        case errors.Is(err, errAttemptSubmitted):
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "Jawaban untuk ujian ini sudah dikumpulkan",
+            })
+        case errors.Is(err, errAttemptExpired):
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "Sesi ujian sudah berakhir",
+            })
+        case errors.Is(err, errExamClosed):
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "Ujian sudah ditutup",
+            })
+        case errors.Is(err, errDeadlinePassed):
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "Waktu ujian sudah habis, jawaban terakhir yang tersimpan akan dikumpulkan otomatis",
+            })
+        case err != nil:
+            log.Printf("Gagal menyimpan jawaban ujian %d untuk peserta %d: %v", exam.ID, uint(userID), err)
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menyimpan jawaban, silakan coba lagi",
+            })
+        }
+        if replay {
+            return c.JSON(submitResponse(attempt))
+        }
+
+        // Draft di Redis baru dibersihkan setelah commit berhasil
+        if err := clearDrafts(attempt.ID); err != nil {
+            log.Printf("Gagal menghapus draft attempt %d: %v", attempt.ID, err)
+        }
+        
+        return c.JSON(submitResponse(attempt))
+    })
+
+    // Get participant's own result
+    app.Get("/api/exam/:id/result", authMiddleware, verifiedMiddleware, requirePermission(auth.PermExamsTake), func(c *fiber.Ctx) error {
+        userID := c.Locals("user_id").(float64)
+        var result models.Result
+        if err := db.Where("exam_id = ? AND participant_id = ?", c.Params("id"), uint(userID)).First(&result).Error; err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Hasil ujian belum tersedia",
+            })
+        }
+        return c.JSON(resultResponse(result))
+    })
+
+    // Get exam timer endpoint
+    app.Get("/api/exam/:id/timer", authMiddleware, verifiedMiddleware, requirePermission(auth.PermExamsTake), func(c *fiber.Ctx) error {
+        userID := c.Locals("user_id").(float64)
+        examID := c.Params("id")
+        
+        // Get session from Redis
+        sessionKey := fmt.Sprintf("exam_session:%d:%s", uint(userID), examID)
+        sessionData, err := store.Storage.Get(sessionKey)
+        if err != nil || sessionData == nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Sesi ujian tidak ditemukan",
+            })
+        }
+        
+        var session ExamSession
+        if err := json.Unmarshal(sessionData, &session); err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal membaca data sesi",
+            })
+        }
+        
+        remainingTime := session.Duration - int(time.Since(session.StartTime).Seconds())
+        if remainingTime < 0 {
+            remainingTime = 0
+        }
+        
+        return c.JSON(fiber.Map{
+            "success": true,
+            "remaining_time": remainingTime,
+        })
+    })
+
+    // Draft jawaban attempt yang sedang berjalan, untuk memulihkan jawaban
+    // setelah browser ditutup atau halaman dimuat ulang
+    app.Get("/api/exam/:id/drafts", authMiddleware, verifiedMiddleware, requirePermission(auth.PermExamsTake), func(c *fiber.Ctx) error {
+        examID, err := c.ParamsInt("id")
+        if err != nil {
+            return examNotFound(c)
+        }
+        attempt, err := runningAttempt(db, uint(examID), currentUserID(c))
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil jawaban sementara",
+            })
+        }
+        if attempt.ID == 0 {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Tidak ada ujian yang sedang berjalan",
+            })
+        }
+        saved, err := loadDrafts(attempt.ID)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengambil jawaban sementara",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success":    true,
+            "attempt_id": attempt.ID,
+            "drafts":     saved,
+        })
+    })
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http/httptest"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/alicebob/miniredis/v2"
+    "github.com/gofiber/fiber/v2"
+    "github.com/gofiber/fiber/v2/middleware/session"
+    "github.com/gofiber/storage/redis"
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/driver/sqlite"
+    "gorm.io/gorm"
+    "gorm.io/gorm/logger"
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/drafts"
+    "online-exam-app-backend/models"
+    "online-exam-app-backend/utils"
+)
+
+// newTestApp menyiapkan rute dari register di atas SQLite dan miniredis sebagai
+// pengganti Postgres dan Redis. Global yang diganti dikembalikan setelah test.
+func newTestApp(t *testing.T, register func(fiber.Router, *gorm.DB)) *fiber.App {
+    t.Helper()
+    savedConfig, savedDB, savedStore, savedRdb := config, db, store, rdb
+    savedTokens, savedSessions, savedDrafts, savedGuard := tokens, sessions, draftStore, loginGuard
+    savedPasswords := passwords
+    t.Cleanup(func() {
+        config, db, store, rdb = savedConfig, savedDB, savedStore, savedRdb
+        tokens, sessions, draftStore, loginGuard = savedTokens, savedSessions, savedDrafts, savedGuard
+        passwords = savedPasswords
+    })
+
+    config = &utils.Config{
+        JWTSecret:          strings.Repeat("s", 32),
+        JWTAlgorithm:       "HS256",
+        JWTKeyID:           "test",
+        AccessTokenTTL:     time.Minute,
+        RefreshTokenTTL:    time.Hour,
+        DeadlineGrace:      30 * time.Second,
+        DraftRetention:     time.Hour,
+        DraftStorage:       "redis",
+        LoginMaxFailures:   3,
+        LoginFailureWindow: time.Minute,
+        LoginLockoutTTL:    time.Minute,
+    }
+    passwords = auth.NewPasswordHasher(bcrypt.MinCost)
+    var err error
+    tokens, err = auth.NewTokenServiceFromConfig(config)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    db, err = gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := db.AutoMigrate(&User{}, &models.Exam{}, &models.ExamOverride{}, &models.Attempt{}, &models.Question{}, &models.Answer{}, &models.AnswerGrade{}, &models.Result{}, &models.Group{}, &models.GroupMember{}, &models.ExamGroup{}); err != nil {
+        t.Fatal(err)
+    }
+
+    mr := miniredis.RunT(t)
+    port, _ := strconv.Atoi(mr.Port())
+    redisStorage := redis.New(redis.Config{Host: mr.Host(), Port: port})
+    store = session.New(session.Config{Storage: redisStorage})
+    rdb = redisStorage.Conn()
+    sessions = auth.NewSessionManager(store.Storage, rdb, tokens, config.AccessTokenTTL, config.RefreshTokenTTL)
+    loginGuard = auth.NewLoginGuard(rdb, config.LoginMaxFailures, config.LoginFailureWindow, config.LoginLockoutTTL)
+    if draftStore, err = drafts.New(config, rdb, db); err != nil {
+        t.Fatal(err)
+    }
+
+    app := fiber.New()
+    register(app, db)
+    return app
+}
+
+// newTestParticipant membuat peserta terverifikasi dengan password "rahasia123"
+// dan mengembalikan access token-nya
+func newTestParticipant(t *testing.T, email string) (User, string) {
+    t.Helper()
+    return newTestUser(t, email, auth.RoleParticipant)
+}
+
+// newTestUser membuat user terverifikasi dengan role tertentu
+func newTestUser(t *testing.T, email, role string) (User, string) {
+    t.Helper()
+    hash, err := passwords.Hash("rahasia123")
+    if err != nil {
+        t.Fatal(err)
+    }
+    user := User{Email: email, Password: hash, Role: role, EmailVerified: true}
+    if err := db.Create(&user).Error; err != nil {
+        t.Fatal(err)
+    }
+    pair, err := sessions.Issue(user.identity())
+    if err != nil {
+        t.Fatal(err)
+    }
+    return user, pair.AccessToken
+}
+
+// callJSON mengirim request ke app dan mengembalikan status serta body JSON-nya
+func callJSON(t *testing.T, app *fiber.App, method, path, token string, body interface{}) (int, interface{}) {
+    t.Helper()
+    var reader io.Reader
+    if body != nil {
+        data, _ := json.Marshal(body)
+        reader = bytes.NewReader(data)
+    }
+    req := httptest.NewRequest(method, path, reader)
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("Authorization", "Bearer "+token)
+    resp, err := app.Test(req, -1)
+    if err != nil {
+        t.Fatal(err)
+    }
+    raw, _ := io.ReadAll(resp.Body)
+    var decoded interface{}
+    if err := json.Unmarshal(raw, &decoded); err != nil {
+        t.Fatalf("%s %s: respons bukan JSON: %s", method, path, raw)
+    }
+    return resp.StatusCode, decoded
+}
+
+func TestParticipantRoutesHideAnswerKey(t *testing.T) {
+    app := newTestApp(t, registerExamRoutes)
+    user, token := newTestParticipant(t, "peserta@example.com")
+
+    exam := models.Exam{Title: "Ujian", Duration: 600, MaxAttempts: 1, GradingPolicy: "last"}
+    if err := db.Create(&exam).Error; err != nil {
+        t.Fatal(err)
+    }
+    group := models.Group{Name: "Kelas"}
+    if err := db.Create(&group).Error; err != nil {
+        t.Fatal(err)
+    }
+    db.Create(&models.GroupMember{GroupID: group.ID, UserID: user.ID})
+    db.Create(&models.ExamGroup{ExamID: exam.ID, GroupID: group.ID})
+    questions := secretQuestions()
+    for i := range questions {
+        questions[i].ID = 0
+        questions[i].ExamID = exam.ID
+    }
+    if err := db.Create(&questions).Error; err != nil {
+        t.Fatal(err)
+    }
+
+    status, body := callJSON(t, app, "GET", fmt.Sprintf("/api/exam/%d/questions", exam.ID), token, nil)
+    if status != fiber.StatusOK {
+        t.Fatalf("questions: status = %d, body = %v", status, body)
+    }
+    assertNoForbiddenKeys(t, "GET /api/exam/:id/questions", body)
+
+    if status, body = callJSON(t, app, "POST", fmt.Sprintf("/api/exam/%d/start", exam.ID), token, nil); status != fiber.StatusOK {
+        t.Fatalf("start: status = %d, body = %v", status, body)
+    }
+
+    answers := make([]fiber.Map, 0, len(questions))
+    for _, q := range questions {
+        answers = append(answers, fiber.Map{"question_id": q.ID, "answer_text": q.CorrectAnswer})
+    }
+    status, body = callJSON(t, app, "POST", "/api/answers/submit", token, fiber.Map{"exam_id": exam.ID, "answers": answers})
+    if status != fiber.StatusOK {
+        t.Fatalf("submit: status = %d, body = %v", status, body)
+    }
+    assertNoForbiddenKeys(t, "POST /api/answers/submit", body)
+
+    status, body = callJSON(t, app, "GET", fmt.Sprintf("/api/exam/%d/result", exam.ID), token, nil)
+    if status != fiber.StatusOK {
+        t.Fatalf("result: status = %d, body = %v", status, body)
+    }
+    assertNoForbiddenKeys(t, "GET /api/exam/:id/result", body)
+}
+
+func TestExamsWithoutGroupsAreOpenToAllParticipants(t *testing.T) {
+    app := newTestApp(t, registerExamRoutes)
+    _, token := newTestParticipant(t, "peserta@example.com")
+
+    open := models.Exam{Title: "Tanpa group", Duration: 600, GradingPolicy: "last"}
+    other := models.Exam{Title: "Kelas lain", Duration: 600, GradingPolicy: "last"}
+    db.Create(&open)
+    db.Create(&other)
+    group := models.Group{Name: "Kelas lain"}
+    db.Create(&group)
+    db.Create(&models.ExamGroup{ExamID: other.ID, GroupID: group.ID})
+
+    status, body := callJSON(t, app, "GET", "/api/exams", token, nil)
+    if status != fiber.StatusOK {
+        t.Fatalf("status = %d, body = %v", status, body)
+    }
+    exams, _ := body.([]interface{})
+    if len(exams) != 1 || exams[0].(map[string]interface{})["title"] != open.Title {
+        t.Errorf("ujian terlihat = %v, harus hanya %q", body, open.Title)
+    }
+    if status, _ := callJSON(t, app, "GET", fmt.Sprintf("/api/exam/%d/questions", other.ID), token, nil); status != fiber.StatusNotFound {
+        t.Errorf("ujian group lain: status = %d, harus 404", status)
+    }
+}
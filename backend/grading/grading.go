@@ -0,0 +1,246 @@
+package grading
+
+import (
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+    "online-exam-app-backend/models"
+)
+
+// Scorer menilai satu jawaban dan mengembalikan porsi benar antara 0 dan 1
+type Scorer func(q models.Question, answer string) float64
+
+// scorers memetakan tipe soal ke fungsi penilaiannya
+var scorers = map[string]Scorer{
+    models.QuestionSingleChoice:   scoreSingleChoice,
+    models.QuestionMultipleChoice: scoreMultipleChoice,
+    models.QuestionTrueFalse:      scoreTrueFalse,
+    models.QuestionNumeric:        scoreNumeric,
+    models.QuestionFillBlank:      scoreFillBlank,
+    models.QuestionMatching:       scoreMatching,
+    models.QuestionOrdering:       scoreOrdering,
+}
+
+// QuestionScore adalah rincian nilai per soal
+type QuestionScore struct {
+    QuestionID uint    `json:"question_id"`
+    Points     float64 `json:"points"`
+    MaxPoints  float64 `json:"max_points"`
+    Pending    bool    `json:"pending,omitempty"` // menunggu dinilai manual
+}
+
+// Outcome adalah hasil penilaian seluruh soal dalam satu ujian
+type Outcome struct {
+    Score     float64         `json:"score"`
+    MaxScore  float64         `json:"max_score"`
+    Pending   int             `json:"pending"` // jumlah jawaban yang belum dinilai manual
+    Questions []QuestionScore `json:"questions"`
+}
+
+// Grade menilai jawaban peserta terhadap daftar soal ujian. Jika satu soal
+// memiliki lebih dari satu jawaban, jawaban terakhir di slice yang dipakai.
+// Soal tanpa jawaban tetap dihitung ke skor maksimum. Soal yang dinilai
+// manual memakai poin dari manual (per question_id); jawaban yang belum
+// dinilai dihitung 0 dan ditandai pending.
+func Grade(questions []models.Question, answers []models.Answer, manual map[uint]float64) Outcome {
+    latest := make(map[uint]string, len(answers))
+    for _, a := range answers {
+        latest[a.QuestionID] = a.AnswerText
+    }
+
+    var out Outcome
+    for _, q := range questions {
+        max := float64(weightOf(q))
+        qs := QuestionScore{QuestionID: q.ID, MaxPoints: max}
+        if answer, ok := latest[q.ID]; ok {
+            if q.ManualGraded() {
+                if points, graded := manual[q.ID]; graded {
+                    qs.Points = max * clamp(points/max)
+                } else if strings.TrimSpace(answer) != "" {
+                    qs.Pending = true
+                    out.Pending++
+                }
+            } else if score, ok := scorers[typeOf(q)]; ok && strings.TrimSpace(answer) != "" {
+                // Jawaban kosong tidak pernah mendapat poin, apa pun kuncinya
+                qs.Points = max * clamp(score(q, answer))
+            }
+        }
+        out.Score += qs.Points
+        out.MaxScore += qs.MaxPoints
+        out.Questions = append(out.Questions, qs)
+    }
+    return out
+}
+
+// GradeAttempt menilai jawaban final satu attempt, menyimpan skornya ke
+// attempt tersebut, lalu memperbarui nilai peserta di tabel results.
+// Dipanggil ulang setiap kali guru menilai jawaban manual.
+func GradeAttempt(db *gorm.DB, attempt *models.Attempt) (*models.Result, error) {
+    var questions []models.Question
+    if err := db.Where("exam_id = ?", attempt.ExamID).Order("id").Find(&questions).Error; err != nil {
+        return nil, err
+    }
+    var answers []models.Answer
+    err := db.Where("attempt_id = ? AND is_draft = ?", attempt.ID, false).
+        Order("submitted_at, id").
+        Find(&answers).Error
+    if err != nil {
+        return nil, err
+    }
+    manual, err := manualPoints(db, answers)
+    if err != nil {
+        return nil, err
+    }
+
+    outcome := Grade(questions, answers, manual)
+    attempt.Score = outcome.Score
+    attempt.MaxScore = outcome.MaxScore
+    attempt.ResultStatus = models.ResultGraded
+    if outcome.Pending > 0 {
+        attempt.ResultStatus = models.ResultPending
+    }
+    err = db.Model(attempt).Updates(map[string]interface{}{
+        "score":         outcome.Score,
+        "max_score":     outcome.MaxScore,
+        "result_status": attempt.ResultStatus,
+    }).Error
+    if err != nil {
+        return nil, err
+    }
+
+    return RecordResult(db, attempt.ExamID, attempt.ParticipantID)
+}
+
+// manualPoints mengambil nilai manual untuk jawaban-jawaban attempt,
+// dipetakan per question_id
+func manualPoints(db *gorm.DB, answers []models.Answer) (map[uint]float64, error) {
+    questionOf := make(map[uint]uint, len(answers))
+    answerIDs := make([]uint, 0, len(answers))
+    for _, a := range answers {
+        questionOf[a.ID] = a.QuestionID
+        answerIDs = append(answerIDs, a.ID)
+    }
+    points := make(map[uint]float64)
+    if len(answerIDs) == 0 {
+        return points, nil
+    }
+    var grades []models.AnswerGrade
+    if err := db.Where("answer_id IN ?", answerIDs).Find(&grades).Error; err != nil {
+        return nil, err
+    }
+    for _, g := range grades {
+        points[questionOf[g.AnswerID]] = g.Points
+    }
+    return points, nil
+}
+
+// RecordResult menghitung ulang nilai peserta dari semua attempt yang sudah
+// dikumpulkan sesuai grading_policy ujian (best, last atau average), lalu
+// menyimpannya ke tabel results
+func RecordResult(db *gorm.DB, examID, participantID uint) (*models.Result, error) {
+    var exam models.Exam
+    if err := db.Select("id", "grading_policy").First(&exam, examID).Error; err != nil {
+        return nil, err
+    }
+    var attempts []models.Attempt
+    err := db.Where("exam_id = ? AND participant_id = ? AND status IN ?", examID, participantID, models.FinishedAttemptStatuses).
+        Order("submitted_at, id").
+        Find(&attempts).Error
+    if err != nil {
+        return nil, err
+    }
+    if len(attempts) == 0 {
+        return nil, gorm.ErrRecordNotFound
+    }
+
+    result := models.Result{
+        ExamID:        examID,
+        ParticipantID: participantID,
+        Attempts:      len(attempts),
+        Status:        models.ResultGraded,
+        GradedAt:      time.Now(),
+    }
+    // Nilai akhir baru final setelah semua attempt selesai dinilai
+    for _, a := range attempts {
+        if a.ResultStatus == models.ResultPending {
+            result.Status = models.ResultPending
+        }
+    }
+    switch exam.GradingPolicy {
+    case models.GradingBest:
+        best := attempts[0]
+        for _, a := range attempts[1:] {
+            if ratio(a) > ratio(best) {
+                best = a
+            }
+        }
+        result.Score, result.MaxScore = best.Score, best.MaxScore
+    case models.GradingAverage:
+        for _, a := range attempts {
+            result.Score += a.Score
+            result.MaxScore += a.MaxScore
+        }
+        result.Score /= float64(len(attempts))
+        result.MaxScore /= float64(len(attempts))
+    default:
+        last := attempts[len(attempts)-1]
+        result.Score, result.MaxScore = last.Score, last.MaxScore
+    }
+
+    err = db.Clauses(clause.OnConflict{
+        Columns:   []clause.Column{{Name: "exam_id"}, {Name: "participant_id"}},
+        DoUpdates: clause.AssignmentColumns([]string{"score", "max_score", "attempts", "status", "graded_at"}),
+    }).Create(&result).Error
+    if err != nil {
+        return nil, err
+    }
+    return &result, nil
+}
+
+// ratio adalah porsi skor terhadap skor maksimum, dipakai untuk membandingkan
+// attempt yang soalnya mungkin sudah berubah bobot
+func ratio(a models.Attempt) float64 {
+    if a.MaxScore <= 0 {
+        return 0
+    }
+    return a.Score / a.MaxScore
+}
+
+func scoreSingleChoice(q models.Question, answer string) float64 {
+    answer = strings.TrimSpace(answer)
+    if answer != "" && answer == strings.TrimSpace(q.CorrectAnswer) {
+        return 1
+    }
+    return 0
+}
+
+// MaxPoints adalah poin maksimum soal (bobotnya, minimal 1)
+func MaxPoints(q models.Question) float64 {
+    return float64(weightOf(q))
+}
+
+func weightOf(q models.Question) int {
+    if q.Weight <= 0 {
+        return 1
+    }
+    return q.Weight
+}
+
+func typeOf(q models.Question) string {
+    if q.Type == "" {
+        return models.QuestionSingleChoice
+    }
+    return q.Type
+}
+
+func clamp(v float64) float64 {
+    if v < 0 {
+        return 0
+    }
+    if v > 1 {
+        return 1
+    }
+    return v
+}
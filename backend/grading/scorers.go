@@ -0,0 +1,185 @@
+package grading
+
+import (
+    "encoding/json"
+    "math"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "online-exam-app-backend/models"
+)
+
+// scoreMultipleChoice menilai jawaban berupa array JSON opsi yang dipilih,
+// misalnya ["A","C"], sesuai aturan partial_credit
+func scoreMultipleChoice(q models.Question, answer string) float64 {
+    key := q.AnswerKey
+    if key == nil || len(key.Choices) == 0 {
+        return 0
+    }
+    var selected []string
+    if err := json.Unmarshal([]byte(answer), &selected); err != nil {
+        return 0
+    }
+    correct := make(map[string]bool, len(key.Choices))
+    for _, c := range key.Choices {
+        correct[c] = true
+    }
+    seen := make(map[string]bool, len(selected))
+    var right, wrong int
+    for _, s := range selected {
+        if seen[s] {
+            continue
+        }
+        seen[s] = true
+        if correct[s] {
+            right++
+        } else {
+            wrong++
+        }
+    }
+
+    switch key.PartialCredit {
+    case models.CreditPartial:
+        if wrong > 0 {
+            return 0
+        }
+        return float64(right) / float64(len(correct))
+    case models.CreditRightMinusWrong:
+        return clamp(float64(right-wrong) / float64(len(correct)))
+    default:
+        if right == len(correct) && wrong == 0 {
+            return 1
+        }
+        return 0
+    }
+}
+
+// scoreTrueFalse menerima "Benar"/"Salah" (opsi yang ditampilkan) maupun "true"/"false"
+func scoreTrueFalse(q models.Question, answer string) float64 {
+    if q.AnswerKey == nil || q.AnswerKey.Value == nil {
+        return 0
+    }
+    var value bool
+    switch strings.ToLower(strings.TrimSpace(answer)) {
+    case "benar", "true":
+        value = true
+    case "salah", "false":
+        value = false
+    default:
+        return 0
+    }
+    if value == *q.AnswerKey.Value {
+        return 1
+    }
+    return 0
+}
+
+// scoreNumeric membandingkan angka dengan toleransi absolut atau relatif.
+// Koma desimal (3,14) diterima selain titik.
+func scoreNumeric(q models.Question, answer string) float64 {
+    key := q.AnswerKey
+    if key == nil || key.Number == nil {
+        return 0
+    }
+    value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(answer), ",", "."), 64)
+    if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+        return 0
+    }
+    tolerance := key.Tolerance
+    if key.ToleranceMode == models.ToleranceRelative {
+        tolerance = key.Tolerance * math.Abs(*key.Number)
+    }
+    // Selisih kecil dari pembulatan float tidak boleh membuat jawaban salah
+    if math.Abs(value-*key.Number) <= tolerance+1e-9 {
+        return 1
+    }
+    return 0
+}
+
+// scoreFillBlank mencocokkan jawaban dengan salah satu jawaban yang diterima.
+// Spasi berlebih diabaikan; regex harus cocok dengan seluruh jawaban.
+func scoreFillBlank(q models.Question, answer string) float64 {
+    key := q.AnswerKey
+    accepted := []string{q.CorrectAnswer}
+    caseSensitive, useRegex := false, false
+    if key != nil && len(key.Accepted) > 0 {
+        accepted, caseSensitive, useRegex = key.Accepted, key.CaseSensitive, key.Regex
+    }
+    answer = normalizeSpace(answer)
+    if answer == "" {
+        return 0
+    }
+    for _, a := range accepted {
+        if useRegex {
+            pattern := "^(?:" + a + ")$"
+            if !caseSensitive {
+                pattern = "(?i)" + pattern
+            }
+            if re, err := regexp.Compile(pattern); err == nil && re.MatchString(answer) {
+                return 1
+            }
+            continue
+        }
+        a = normalizeSpace(a)
+        if (caseSensitive && answer == a) || (!caseSensitive && strings.EqualFold(answer, a)) {
+            return 1
+        }
+    }
+    return 0
+}
+
+// scoreMatching menilai jawaban berupa objek JSON pernyataan ke pasangannya,
+// misalnya {"Soekarno":"Proklamator"}
+func scoreMatching(q models.Question, answer string) float64 {
+    key := q.AnswerKey
+    if key == nil || len(key.Pairs) == 0 {
+        return 0
+    }
+    var matched map[string]string
+    if err := json.Unmarshal([]byte(answer), &matched); err != nil {
+        return 0
+    }
+    right := 0
+    for _, pair := range key.Pairs {
+        if matched[pair.Left] == pair.Right {
+            right++
+        }
+    }
+    return pairCredit(key, right, len(key.Pairs))
+}
+
+// scoreOrdering menilai jawaban berupa array JSON item sesuai urutan peserta.
+// Untuk per_pair, setiap item di posisi yang benar bernilai sama.
+func scoreOrdering(q models.Question, answer string) float64 {
+    key := q.AnswerKey
+    if key == nil || len(key.Sequence) == 0 {
+        return 0
+    }
+    // Jawaban harus memuat seluruh item, bukan sebagian atau dengan tambahan
+    var order []string
+    if err := json.Unmarshal([]byte(answer), &order); err != nil || len(order) != len(key.Sequence) {
+        return 0
+    }
+    right := 0
+    for i, item := range key.Sequence {
+        if order[i] == item {
+            right++
+        }
+    }
+    return pairCredit(key, right, len(key.Sequence))
+}
+
+func pairCredit(key *models.AnswerKey, right, total int) float64 {
+    if key.PartialCredit == models.CreditPerPair {
+        return float64(right) / float64(total)
+    }
+    if right == total {
+        return 1
+    }
+    return 0
+}
+
+func normalizeSpace(s string) string {
+    return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,177 @@
+package grading
+
+import (
+    "math"
+    "testing"
+
+    "online-exam-app-backend/models"
+)
+
+type scorerCase struct {
+    name   string
+    q      models.Question
+    answer string
+    want   float64
+}
+
+func runScorerCases(t *testing.T, score func(models.Question, string) float64, cases []scorerCase) {
+    t.Helper()
+    for _, tc := range cases {
+        t.Run(tc.name, func(t *testing.T) {
+            if got := score(tc.q, tc.answer); math.Abs(got-tc.want) > 1e-9 {
+                t.Errorf("skor %q = %v, harus %v", tc.answer, got, tc.want)
+            }
+        })
+    }
+}
+
+func multipleChoice(credit string) models.Question {
+    return models.Question{
+        Type:      models.QuestionMultipleChoice,
+        Options:   []string{"A", "B", "C", "D"},
+        AnswerKey: &models.AnswerKey{Choices: []string{"A", "B", "C"}, PartialCredit: credit},
+    }
+}
+
+func TestScoreMultipleChoice(t *testing.T) {
+    runScorerCases(t, scoreMultipleChoice, []scorerCase{
+        {"all_or_nothing tepat", multipleChoice(models.CreditAllOrNothing), `["C","A","B"]`, 1},
+        {"all_or_nothing kurang satu", multipleChoice(models.CreditAllOrNothing), `["A","B"]`, 0},
+        {"all_or_nothing dengan salah", multipleChoice(models.CreditAllOrNothing), `["A","B","C","D"]`, 0},
+        {"default sama dengan all_or_nothing", multipleChoice(""), `["A","B"]`, 0},
+        {"partial sebagian", multipleChoice(models.CreditPartial), `["A","B"]`, 2.0 / 3},
+        {"partial dengan salah bernilai 0", multipleChoice(models.CreditPartial), `["A","B","D"]`, 0},
+        {"partial pilihan ganda dihitung sekali", multipleChoice(models.CreditPartial), `["A","A","A"]`, 1.0 / 3},
+        {"right_minus_wrong", multipleChoice(models.CreditRightMinusWrong), `["A","B","D"]`, 1.0 / 3},
+        {"right_minus_wrong minimal 0", multipleChoice(models.CreditRightMinusWrong), `["A","D"]`, 0},
+        {"right_minus_wrong semua benar", multipleChoice(models.CreditRightMinusWrong), `["A","B","C"]`, 1},
+        {"bukan array JSON", multipleChoice(models.CreditPartial), `A,B`, 0},
+        {"kosong", multipleChoice(models.CreditPartial), `[]`, 0},
+    })
+}
+
+func TestScoreTrueFalse(t *testing.T) {
+    yes := true
+    q := models.Question{Type: models.QuestionTrueFalse, AnswerKey: &models.AnswerKey{Value: &yes}}
+    runScorerCases(t, scoreTrueFalse, []scorerCase{
+        {"opsi tampilan", q, "Benar", 1},
+        {"huruf kecil dan spasi", q, "  benar ", 1},
+        {"true", q, "TRUE", 1},
+        {"salah", q, "Salah", 0},
+        {"tidak dikenal", q, "ya", 0},
+        {"tanpa kunci", models.Question{Type: models.QuestionTrueFalse}, "Benar", 0},
+    })
+}
+
+func numeric(number, tolerance float64, mode string) models.Question {
+    return models.Question{
+        Type:      models.QuestionNumeric,
+        AnswerKey: &models.AnswerKey{Number: &number, Tolerance: tolerance, ToleranceMode: mode},
+    }
+}
+
+func TestScoreNumeric(t *testing.T) {
+    runScorerCases(t, scoreNumeric, []scorerCase{
+        {"tepat", numeric(3.14, 0, models.ToleranceAbsolute), "3.14", 1},
+        {"koma desimal", numeric(3.14, 0, models.ToleranceAbsolute), "3,14", 1},
+        {"absolut di batas toleransi", numeric(3.14, 0.01, models.ToleranceAbsolute), "3.15", 1},
+        {"absolut di luar toleransi", numeric(3.14, 0.01, models.ToleranceAbsolute), "3.16", 0},
+        {"relatif 1% di dalam", numeric(200, 0.01, models.ToleranceRelative), "198", 1},
+        {"relatif 1% di luar", numeric(200, 0.01, models.ToleranceRelative), "197.9", 0},
+        {"relatif kunci negatif", numeric(-50, 0.1, models.ToleranceRelative), "-45", 1},
+        {"bukan angka", numeric(3, 1, models.ToleranceAbsolute), "tiga", 0},
+        {"NaN ditolak", numeric(3, 1, models.ToleranceAbsolute), "NaN", 0},
+    })
+}
+
+func fillBlank(key *models.AnswerKey, correct string) models.Question {
+    return models.Question{Type: models.QuestionFillBlank, AnswerKey: key, CorrectAnswer: correct}
+}
+
+func TestScoreFillBlank(t *testing.T) {
+    accepted := &models.AnswerKey{Accepted: []string{"Jakarta", "DKI  Jakarta"}}
+    caseSensitive := &models.AnswerKey{Accepted: []string{"H2O"}, CaseSensitive: true}
+    regex := &models.AnswerKey{Accepted: []string{`(ir\.?\s*)?soekarno`, `bung karno`}, Regex: true}
+    regexSensitive := &models.AnswerKey{Accepted: []string{`[A-Z]{2}`}, Regex: true, CaseSensitive: true}
+    runScorerCases(t, scoreFillBlank, []scorerCase{
+        {"huruf besar diabaikan", fillBlank(accepted, ""), "jakarta", 1},
+        {"spasi berlebih diabaikan", fillBlank(accepted, ""), "  dki jakarta ", 1},
+        {"tidak diterima", fillBlank(accepted, ""), "Bandung", 0},
+        {"case sensitive cocok", fillBlank(caseSensitive, ""), "H2O", 1},
+        {"case sensitive beda huruf", fillBlank(caseSensitive, ""), "h2o", 0},
+        {"regex tanpa membedakan huruf", fillBlank(regex, ""), "Ir. Soekarno", 1},
+        {"regex alternatif kedua", fillBlank(regex, ""), "BUNG KARNO", 1},
+        {"regex harus cocok seluruh jawaban", fillBlank(regex, ""), "presiden soekarno", 0},
+        {"regex case sensitive", fillBlank(regexSensitive, ""), "ab", 0},
+        {"regex case sensitive cocok", fillBlank(regexSensitive, ""), "AB", 1},
+        {"kunci lama correct_answer", fillBlank(nil, "Merdeka"), "merdeka", 1},
+        {"jawaban kosong", fillBlank(nil, ""), "", 0},
+    })
+}
+
+func matching(credit string) models.Question {
+    return models.Question{
+        Type: models.QuestionMatching,
+        AnswerKey: &models.AnswerKey{
+            Pairs: []models.MatchPair{
+                {Left: "Soekarno", Right: "Presiden pertama"},
+                {Left: "Hatta", Right: "Wakil presiden pertama"},
+                {Left: "Kartini", Right: "Emansipasi wanita"},
+                {Left: "Dewantara", Right: "Pendidikan"},
+            },
+            PartialCredit: credit,
+        },
+    }
+}
+
+func TestScoreMatching(t *testing.T) {
+    all := `{"Soekarno":"Presiden pertama","Hatta":"Wakil presiden pertama","Kartini":"Emansipasi wanita","Dewantara":"Pendidikan"}`
+    half := `{"Soekarno":"Presiden pertama","Hatta":"Wakil presiden pertama","Kartini":"Pendidikan","Dewantara":"Emansipasi wanita"}`
+    runScorerCases(t, scoreMatching, []scorerCase{
+        {"all_or_nothing semua benar", matching(models.CreditAllOrNothing), all, 1},
+        {"all_or_nothing sebagian", matching(models.CreditAllOrNothing), half, 0},
+        {"default sama dengan all_or_nothing", matching(""), half, 0},
+        {"per_pair semua benar", matching(models.CreditPerPair), all, 1},
+        {"per_pair sebagian", matching(models.CreditPerPair), half, 0.5},
+        {"per_pair pasangan belum diisi", matching(models.CreditPerPair), `{"Soekarno":"Presiden pertama"}`, 0.25},
+        {"pernyataan asing diabaikan", matching(models.CreditPerPair), `{"Sudirman":"Presiden pertama"}`, 0},
+        {"bukan objek JSON", matching(models.CreditPerPair), `["Presiden pertama"]`, 0},
+    })
+}
+
+func ordering(credit string) models.Question {
+    return models.Question{
+        Type: models.QuestionOrdering,
+        AnswerKey: &models.AnswerKey{
+            Sequence:      []string{"Sumpah Pemuda", "Proklamasi", "KMB", "Supersemar"},
+            PartialCredit: credit,
+        },
+    }
+}
+
+func TestScoreOrdering(t *testing.T) {
+    correct := `["Sumpah Pemuda","Proklamasi","KMB","Supersemar"]`
+    swapped := `["Sumpah Pemuda","Proklamasi","Supersemar","KMB"]`
+    runScorerCases(t, scoreOrdering, []scorerCase{
+        {"all_or_nothing urutan benar", ordering(models.CreditAllOrNothing), correct, 1},
+        {"all_or_nothing dua item tertukar", ordering(models.CreditAllOrNothing), swapped, 0},
+        {"per_pair urutan benar", ordering(models.CreditPerPair), correct, 1},
+        {"per_pair dua item tertukar", ordering(models.CreditPerPair), swapped, 0.5},
+        {"per_pair terbalik", ordering(models.CreditPerPair), `["Supersemar","KMB","Proklamasi","Sumpah Pemuda"]`, 0},
+        {"item kurang", ordering(models.CreditPerPair), `["Sumpah Pemuda","Proklamasi"]`, 0},
+        {"item berlebih", ordering(models.CreditAllOrNothing), `["Sumpah Pemuda","Proklamasi","KMB","Supersemar","Reformasi"]`, 0},
+        {"bukan array JSON", ordering(models.CreditPerPair), `Sumpah Pemuda`, 0},
+    })
+}
+
+func TestGradeBlankAnswerScoresZero(t *testing.T) {
+    questions := []models.Question{
+        {ID: 1, Type: models.QuestionSingleChoice, CorrectAnswer: ""},
+        {ID: 2, Type: models.QuestionSingleChoice, CorrectAnswer: "4"},
+    }
+    answers := []models.Answer{{QuestionID: 1, AnswerText: "  "}, {QuestionID: 2, AnswerText: " 4 "}}
+    out := Grade(questions, answers, nil)
+    if out.Score != 1 || out.MaxScore != 2 {
+        t.Errorf("skor = %v dari %v, harus 1 dari 2", out.Score, out.MaxScore)
+    }
+}
@@ -0,0 +1,87 @@
+package mailer
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "online-exam-app-backend/utils"
+)
+
+// Message adalah email teks sederhana
+type Message struct {
+    To      string
+    Subject string
+    Body    string
+}
+
+// Mailer mengirim email. Implementasi dipilih lewat MAIL_DRIVER.
+type Mailer interface {
+    Send(msg Message) error
+}
+
+// New memilih implementasi mailer dari konfigurasi: log (default), file, atau smtp
+func New(c *utils.Config) (Mailer, error) {
+    switch strings.ToLower(c.MailDriver) {
+    case "", "log":
+        return LogMailer{}, nil
+    case "file":
+        if err := os.MkdirAll(c.MailFileDir, 0o750); err != nil {
+            return nil, err
+        }
+        return FileMailer{Dir: c.MailFileDir, From: c.MailFrom}, nil
+    case "smtp":
+        return &SMTPMailer{
+            Host:     c.SMTPHost,
+            Port:     c.SMTPPort,
+            Username: c.SMTPUser,
+            Password: c.SMTPPass,
+            From:     c.MailFrom,
+        }, nil
+    }
+    return nil, fmt.Errorf("MAIL_DRIVER %q tidak dikenal", c.MailDriver)
+}
+
+// LogMailer menulis email ke log aplikasi, cocok untuk development
+type LogMailer struct{}
+
+func (LogMailer) Send(msg Message) error {
+    log.Printf("[mail] to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+    return nil
+}
+
+// FileMailer menyimpan setiap email sebagai file .eml di Dir
+type FileMailer struct {
+    Dir  string
+    From string
+}
+
+func (m FileMailer) Send(msg Message) error {
+    name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitize(msg.To))
+    return os.WriteFile(filepath.Join(m.Dir, name), format(m.From, msg), 0o640)
+}
+
+// format menyusun pesan RFC 5322 sederhana
+func format(from string, msg Message) []byte {
+    var b strings.Builder
+    fmt.Fprintf(&b, "From: %s\r\n", from)
+    fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+    fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+    fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+    b.WriteString("MIME-Version: 1.0\r\n")
+    b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+    b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+    return []byte(b.String())
+}
+
+func sanitize(s string) string {
+    return strings.Map(func(r rune) rune {
+        if r == '@' || r == '.' || r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+            return r
+        }
+        return '_'
+    }, s)
+}
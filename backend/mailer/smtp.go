@@ -0,0 +1,31 @@
+package mailer
+
+import (
+    "errors"
+    "net"
+    "net/smtp"
+    "strings"
+)
+
+// SMTPMailer mengirim email lewat server SMTP. Autentikasi PLAIN hanya
+// dipakai jika Username diisi, sehingga bisa diarahkan ke server SMTP lokal
+// (mis. MailHog) tanpa kredensial.
+type SMTPMailer struct {
+    Host     string
+    Port     string
+    Username string
+    Password string
+    From     string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+    if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+        return errors.New("header email tidak valid")
+    }
+    var auth smtp.Auth
+    if m.Username != "" {
+        auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+    }
+    addr := net.JoinHostPort(m.Host, m.Port)
+    return smtp.SendMail(addr, auth, m.From, []string{msg.To}, format(m.From, msg))
+}
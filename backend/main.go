@@ -1,67 +1,139 @@
 package main
 
 import (
+    "errors"
     "fmt"
     "log"
     "time"
     "context"
-    "encoding/json"
-    "sync"
     "os"
     "strconv"
+    "strings"
 
     "github.com/gofiber/fiber/v2"
     "github.com/gofiber/fiber/v2/middleware/cors"
     "github.com/gofiber/fiber/v2/middleware/session"
     "github.com/gofiber/storage/redis"
+    goredis "github.com/redis/go-redis/v9"
     "gorm.io/driver/postgres"
     "gorm.io/gorm"
     "github.com/golang-jwt/jwt/v4"
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/drafts"
+    "online-exam-app-backend/mailer"
+    "online-exam-app-backend/models"
     "online-exam-app-backend/utils"
 )
 
+// Error status attempt saat submit
+var (
+    errNoAttempt        = errors.New("attempt tidak ditemukan")
+    errAttemptSubmitted = errors.New("attempt sudah dikumpulkan")
+    errAttemptExpired   = errors.New("attempt sudah kedaluwarsa")
+    errExamClosed       = errors.New("ujian sudah ditutup")
+    errDeadlinePassed   = errors.New("waktu ujian sudah habis")
+    errAttemptLimit     = errors.New("batas percobaan ujian sudah tercapai")
+)
+
 // Global variables
 var (
+    db *gorm.DB
     store *session.Store
-    examTimers sync.Map
     ctx = context.Background()
     config *utils.Config
+    passwords *auth.PasswordHasher
+    tokens *auth.TokenService
+    sessions *auth.SessionManager
+    rdb *goredis.Client
+    mail mailer.Mailer
+    loginGuard *auth.LoginGuard
+    draftStore drafts.Store
 )
 
 // User model
 type User struct {
-    ID       uint   `gorm:"primaryKey"`
+    ID       uint   `gorm:"primaryKey" json:"id"`
     Name     string `json:"name"`
-    Email    string `gorm:"unique"`
-    Password string
-    Role     string `gorm:"default:user"`
+    Email    string `gorm:"unique" json:"email"`
+    Password string `json:"-"` // hash bcrypt, tidak pernah dikirim ke klien
+    Role     string `gorm:"default:participant" json:"role"` // lihat auth.Roles()
+    // Akun lama dan akun buatan admin dianggap sudah terverifikasi;
+    // akun dari /api/register dibuat dengan nilai false
+    EmailVerified bool `gorm:"not null;default:true" json:"email_verified"`
+    // TOTP 2FA: secret terisi saat setup, aktif setelah dikonfirmasi dengan kode
+    TOTPSecret    string   `json:"-"`
+    TOTPEnabled   bool     `gorm:"not null;default:false" json:"totp_enabled"`
+    RecoveryCodes []string `gorm:"serializer:json;type:jsonb" json:"-"` // hash SHA-256
+    // Subject dari identity provider untuk user yang login lewat SSO
+    OIDCSubject *string `gorm:"uniqueIndex" json:"-"`
 }
 
-// Question model
-type Question struct {
-    ID            uint     `gorm:"primaryKey" json:"id"`
-    ExamID        uint     `json:"exam_id"`
-    QuestionText  string   `json:"question_text"`
-    CorrectAnswer string   `json:"correct_answer"`
-    Weight        int      `json:"weight" gorm:"default:1"`
-    Type          string   `json:"type" gorm:"default:'pilihan_ganda'"`
-    Options       []string `gorm:"type:json" json:"options"`
+func (u User) identity() auth.Identity {
+    return auth.Identity{UserID: u.ID, Role: auth.NormalizeRole(u.Role), EmailVerified: u.EmailVerified}
 }
 
-// Answer model
-type Answer struct {
-    ID           uint      `gorm:"primaryKey" json:"id"`
-    ParticipantID uint     `json:"participant_id"`
-    QuestionID   uint      `json:"question_id"`
-    AnswerText   string    `json:"answer_text"`
-    SubmittedAt  time.Time `json:"submitted_at"`
-    IsDraft      bool      `json:"is_draft"`
+func (u User) isAdmin() bool {
+    return auth.NormalizeRole(u.Role) == auth.RoleAdmin
+}
+
+// identityWithMFA menandai apakah sesi sudah lolos verifikasi TOTP
+func (u User) identityWithMFA(mfa bool) auth.Identity {
+    id := u.identity()
+    id.MFA = mfa && u.TOTPEnabled
+    return id
+}
+
+// beginSession menyelesaikan login yang sudah lolos langkah pertama (password
+// atau SSO). User dengan 2FA aktif mendapat challenge token untuk /api/login/2fa.
+func beginSession(c *fiber.Ctx, user User) error {
+    if !user.TOTPEnabled {
+        return loginResponse(c, user, false)
+    }
+    challenge, err := issueMFAChallenge(user)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "success": false,
+            "message": "Gagal membuat token",
+        })
+    }
+    return c.JSON(fiber.Map{
+        "success": true,
+        "mfa_required": true,
+        "challenge_token": challenge,
+        "expires_in": int(mfaChallengeTTL.Seconds()),
+    })
+}
+
+// loginResponse menerbitkan pasangan token dan mengirim respons login berhasil
+func loginResponse(c *fiber.Ctx, user User, mfa bool) error {
+    pair, err := sessions.Issue(user.identityWithMFA(mfa))
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "success": false,
+            "message": "Gagal membuat token",
+        })
+    }
+    return c.JSON(fiber.Map{
+        "success": true,
+        "token": pair.AccessToken,
+        "refresh_token": pair.RefreshToken,
+        "expires_in": pair.ExpiresIn,
+        // Admin tanpa 2FA saat REQUIRE_ADMIN_2FA aktif harus mendaftar dulu
+        "mfa_setup_required": config.RequireAdmin2FA && user.isAdmin() && !user.TOTPEnabled,
+        "user": fiber.Map{
+            "id": user.ID,
+            "email": user.Email,
+            "role": auth.NormalizeRole(user.Role),
+            "permissions": auth.PermissionsFor(user.Role),
+        },
+    })
 }
 
 // ExamSession model untuk Redis
 type ExamSession struct {
     UserID    uint      `json:"user_id"`
     ExamID    uint      `json:"exam_id"`
+    AttemptID uint      `json:"attempt_id"`
     StartTime time.Time `json:"start_time"`
     Duration  int       `json:"duration"` // dalam detik
 }
@@ -86,30 +158,82 @@ func authMiddleware(c *fiber.Ctx) error {
         })
     }
 
-    tokenString := authHeader[len("Bearer "):]
-    token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-        return []byte("secret"), nil
-    })
+    tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+    claims, err := tokens.Parse(tokenString)
+    if err != nil {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "success": false,
+            "message": "Token tidak valid",
+        })
+    }
+
+    // Hanya access token yang boleh dipakai untuk memanggil API
+    if typ, _ := claims["typ"].(string); typ != auth.TokenTypeAccess {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "success": false,
+            "message": "Token tidak valid",
+        })
+    }
 
-    if err != nil || !token.Valid {
+    revoked, err := sessions.IsRevoked(claims)
+    if err != nil || revoked {
         return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
             "success": false,
             "message": "Token tidak valid",
         })
     }
 
-    claims := token.Claims.(jwt.MapClaims)
+    c.Locals("claims", claims)
     c.Locals("user_id", claims["user_id"])
     c.Locals("role", claims["role"])
+    c.Locals("email_verified", claims["email_verified"])
+    c.Locals("mfa", claims["mfa"])
     return c.Next()
 }
 
-// Middleware untuk admin
-func adminMiddleware(c *fiber.Ctx) error {
-    if c.Locals("role") != "admin" {
+// requirePermission menjaga rute dengan permission bernama sesuai role di
+// token. Jika REQUIRE_ADMIN_2FA aktif, token admin juga harus berasal dari
+// login yang lolos verifikasi TOTP.
+func requirePermission(perm auth.Permission) fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        role, _ := c.Locals("role").(string)
+        if !auth.HasPermission(role, perm) {
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Anda tidak memiliki izin untuk aksi ini",
+            })
+        }
+        if mfa, _ := c.Locals("mfa").(bool); config.RequireAdmin2FA && auth.NormalizeRole(role) == auth.RoleAdmin && !mfa {
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "Akun admin wajib login dengan 2FA",
+            })
+        }
+        return c.Next()
+    }
+}
+
+// invalidRole mengirim respons 400 untuk role yang tidak dikenal
+func invalidRole(c *fiber.Ctx) error {
+    return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+        "success": false,
+        "message": "Role tidak valid, pilih salah satu: " + strings.Join(auth.Roles(), ", "),
+    })
+}
+
+// Middleware untuk rute ujian: akun harus sudah verifikasi email.
+// Claim di token dipercaya jika true; jika false, status terbaru dicek ke
+// database agar user yang baru verifikasi tidak perlu login ulang.
+func verifiedMiddleware(c *fiber.Ctx) error {
+    if verified, _ := c.Locals("email_verified").(bool); verified {
+        return c.Next()
+    }
+    var user User
+    userID, _ := c.Locals("user_id").(float64)
+    if err := db.Select("id", "email_verified").First(&user, uint(userID)).Error; err != nil || !user.EmailVerified {
         return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
             "success": false,
-            "message": "Akses admin diperlukan",
+            "message": "Email belum diverifikasi",
         })
     }
     return c.Next()
@@ -118,21 +242,47 @@ func adminMiddleware(c *fiber.Ctx) error {
 func main() {
     // Load configuration
     config = utils.LoadConfig()
+    passwords = auth.NewPasswordHasher(config.BcryptCost)
+    var err error
+    tokens, err = auth.NewTokenServiceFromConfig(config)
+    if err != nil {
+        log.Fatal("Failed to load JWT signing keys:", err)
+    }
 
     // Connect to PostgreSQL with connection pooling
-    db := connectDB()
-    db.AutoMigrate(&User{}, &Question{}, &Answer{})
-
-    // Initialize session store with Redis (Fiber Storage)
+    db = connectDB()
+    db.AutoMigrate(&User{}, &models.Exam{}, &models.ExamOverride{}, &models.Attempt{}, &models.Question{}, &models.Answer{}, &models.AnswerGrade{}, &models.Result{}, &models.Group{}, &models.GroupMember{}, &models.ExamGroup{})
+    // Role lama "user" sekarang bernama participant
+    db.Model(&User{}).Where("role = ? OR role = ''", "user").Update("role", auth.RoleParticipant)
+
+    // Initialize session store with Redis (Fiber Storage). Semua state yang
+    // harus sama di setiap proses Prefork (sesi, rate limit, penguncian login,
+    // draft) disimpan di Redis ini, bukan di memori proses.
+    redisStorage := redis.New(redis.Config{
+        Host:     config.RedisHost,
+        Port:     getRedisPort(config.RedisPort), // konversi string ke int
+        Password: config.RedisPass,
+        Database: 0,
+    })
     store = session.New(session.Config{
-        Storage: redis.New(redis.Config{
-            Host:     config.RedisHost,
-            Port:     getRedisPort(config.RedisPort), // konversi string ke int
-            Password: config.RedisPass,
-            Database: 0,
-        }),
+        Storage:    redisStorage,
         Expiration: 24 * time.Hour,
     })
+    rdb = redisStorage.Conn()
+    sessions = auth.NewSessionManager(store.Storage, rdb, tokens, config.AccessTokenTTL, config.RefreshTokenTTL)
+    loginGuard = auth.NewLoginGuard(rdb, config.LoginMaxFailures, config.LoginFailureWindow, config.LoginLockoutTTL)
+
+    // Penyimpanan draft jawaban sesuai DRAFT_STORAGE
+    draftStore, err = drafts.New(config, rdb, db)
+    if err != nil {
+        log.Fatal("Failed to configure draft storage:", err)
+    }
+
+    // Mailer untuk email reset password dan notifikasi lain
+    mail, err = mailer.New(config)
+    if err != nil {
+        log.Fatal("Failed to configure mailer:", err)
+    }
 
     // Initialize Fiber app with custom config
     app := fiber.New(fiber.Config{
@@ -143,12 +293,17 @@ func main() {
     app.Use(cors.New(cors.Config{
         AllowOrigins: "*",
         AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-        AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+        AllowHeaders: "Origin, Content-Type, Accept, Authorization, Idempotency-Key",
     }))
 
+    // Rate limit per IP dan per akun untuk endpoint yang rawan brute force
+    loginIPLimit := newRateLimiter("login_ip", config.LoginRateLimitIP, config.LoginRateWindow, limitByIP)
+    loginAccountLimit := newRateLimiter("login_account", config.LoginRateLimitAccount, config.LoginRateWindow, limitByEmail)
+    registerLimit := newRateLimiter("register_ip", config.RegisterRateLimit, config.RegisterRateWindow, limitByIP)
+
     // ========== AUTH & USER ENDPOINTS ==========
     // Register endpoint
-    app.Post("/api/register", func(c *fiber.Ctx) error {
+    app.Post("/api/register", registerLimit, func(c *fiber.Ctx) error {
         var req struct {
             Email    string `json:"email"`
             Password string `json:"password"`
@@ -174,21 +329,30 @@ func main() {
             })
         }
         // Simpan user baru
-        user = User{Email: req.Email, Password: req.Password}
-        if err := db.Create(&user).Error; err != nil {
+        hash, err := passwords.Hash(req.Password)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mendaftar user",
+            })
+        }
+        user = User{Email: req.Email, Password: hash, Role: auth.RoleParticipant, EmailVerified: false}
+        // Select eksplisit agar EmailVerified=false tidak diganti default kolom
+        if err := db.Select("Name", "Email", "Password", "Role", "EmailVerified").Create(&user).Error; err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
                 "message": "Gagal mendaftar user",
             })
         }
+        sendVerificationEmail(user)
         return c.JSON(fiber.Map{
             "success": true,
-            "message": "Pendaftaran berhasil",
+            "message": "Pendaftaran berhasil, silakan cek email untuk verifikasi akun",
         })
     })
 
     // Login endpoint
-    app.Post("/api/login", func(c *fiber.Ctx) error {
+    app.Post("/api/login", loginIPLimit, loginAccountLimit, func(c *fiber.Ctx) error {
         var req struct {
             Email    string `json:"email"`
             Password string `json:"password"`
@@ -199,237 +363,143 @@ func main() {
                 "message": "Format data tidak valid",
             })
         }
-        var user User
-        if err := db.Where("email = ? AND password = ?", req.Email, req.Password).First(&user).Error; err != nil {
+        // Akun yang sedang dikunci ditolak tanpa memeriksa password
+        locked, err := loginGuard.LockedFor(req.Email)
+        if err != nil {
+            log.Printf("Gagal memeriksa penguncian login: %v", err)
+        }
+        if locked > 0 {
+            return accountLocked(c, locked)
+        }
+        // Email yang tidak terdaftar juga dihitung agar respons tidak membedakannya
+        loginFailed := func() error {
+            lockout, err := loginGuard.RecordFailure(req.Email)
+            if err != nil {
+                log.Printf("Gagal mencatat login gagal: %v", err)
+            }
+            if lockout > 0 {
+                return accountLocked(c, lockout)
+            }
             return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
                 "message": "Email atau password salah",
             })
         }
-        // Generate JWT
-        claims := jwt.MapClaims{
-            "user_id": user.ID,
-            "role": user.Role,
-            "exp": time.Now().Add(time.Hour * 24).Unix(),
+        var user User
+        if err := db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+            passwords.DummyVerify(req.Password)
+            return loginFailed()
+        }
+        ok, needsRehash := passwords.Verify(user.Password, req.Password)
+        if !ok {
+            return loginFailed()
+        }
+        // Password plaintext lama atau cost lama di-hash ulang setelah login berhasil
+        if needsRehash {
+            if hash, err := passwords.Hash(req.Password); err == nil {
+                if err := db.Model(&user).Update("password", hash).Error; err != nil {
+                    log.Printf("Gagal hash ulang password user %d: %v", user.ID, err)
+                }
+            }
         }
-        token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-        tokenString, err := token.SignedString([]byte("secret"))
-        if err != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                "success": false,
-                "message": "Gagal membuat token",
-            })
+        // Untuk user dengan 2FA, penghitung login gagal baru direset setelah kode TOTP benar
+        if !user.TOTPEnabled {
+            if err := loginGuard.Reset(req.Email); err != nil {
+                log.Printf("Gagal mereset penghitung login gagal: %v", err)
+            }
         }
-        return c.JSON(fiber.Map{
-            "success": true,
-            "token": tokenString,
-            "user": fiber.Map{
-                "id": user.ID,
-                "email": user.Email,
-                "role": user.Role,
-            },
-        })
+        return beginSession(c, user)
     })
 
-    // ========== EXAM ENDPOINTS ==========
-    // List all exams
-    app.Get("/api/exams", authMiddleware, func(c *fiber.Ctx) error {
-        var exams []struct {
-            ID       uint   `json:"id"`
-            Title    string `json:"title"`
-            Duration int    `json:"duration"`
-        }
-        // Ambil dari tabel Exam jika ada
-        type ExamDB struct {
-            ID       uint
-            Title    string
-            Duration int
-        }
-        var dbExams []ExamDB
-        if err := db.Table("exams").Select("id, title, duration").Scan(&dbExams).Error; err != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+    // Refresh token endpoint: refresh token lama langsung tidak berlaku (rotasi)
+    app.Post("/api/token/refresh", func(c *fiber.Ctx) error {
+        var req struct {
+            RefreshToken string `json:"refresh_token"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": "Gagal mengambil data ujian",
+                "message": "Format data tidak valid",
             })
         }
-        for _, e := range dbExams {
-            exams = append(exams, struct {
-                ID       uint   `json:"id"`
-                Title    string `json:"title"`
-                Duration int    `json:"duration"`
-            }{e.ID, e.Title, e.Duration})
-        }
-        return c.JSON(exams)
-    })
-
-    // Get questions for an exam
-    app.Get("/api/exam/:id/questions", authMiddleware, func(c *fiber.Ctx) error {
-        examID := c.Params("id")
-        var questions []struct {
-            ID            uint     `json:"id"`
-            QuestionText  string   `json:"question_text"`
-            Options       []string `json:"options"`
-            CorrectAnswer string   `json:"correct_answer"`
-            Weight        int      `json:"weight"`
-        }
-        // Ambil dari tabel questions
-        type QuestionDB struct {
-            ID            uint
-            ExamID        uint
-            QuestionText  string
-            CorrectAnswer string
-            Weight        int
-            // Asumsi ada kolom options bertipe text/json di DB, jika tidak, dummy
-        }
-        var dbQuestions []QuestionDB
-        if err := db.Table("questions").Where("exam_id = ?", examID).Find(&dbQuestions).Error; err != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+        userID, mfa, err := sessions.Consume(req.RefreshToken)
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
-                "message": "Gagal mengambil soal",
+                "message": "Refresh token tidak valid",
             })
         }
-        for _, q := range dbQuestions {
-            // Dummy options jika tidak ada di DB
-            options := []string{"A", "B", "C", "D"}
-            questions = append(questions, struct {
-                ID            uint     `json:"id"`
-                QuestionText  string   `json:"question_text"`
-                Options       []string `json:"options"`
-                CorrectAnswer string   `json:"correct_answer"`
-                Weight        int      `json:"weight"`
-            }{q.ID, q.QuestionText, options, q.CorrectAnswer, q.Weight})
-        }
-        return c.JSON(questions)
-    })
-
-    // Session handling endpoint
-    app.Post("/api/exam/:id/start", authMiddleware, func(c *fiber.Ctx) error {
-        userID := c.Locals("user_id").(float64)
-        examID, _ := strconv.Atoi(c.Params("id"))
-        
-        // Create exam session
-        session := ExamSession{
-            UserID:    uint(userID),
-            ExamID:    uint(examID),
-            StartTime: time.Now(),
-            Duration:  3600, // 1 hour default
-        }
-        
-        // Store in Redis
-        sessionKey := fmt.Sprintf("exam_session:%d:%d", uint(userID), examID)
-        sessionData, _ := json.Marshal(session)
-        err := store.Storage.Set(sessionKey, sessionData, time.Hour)
-        if err != nil {
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+        var user User
+        if err := db.First(&user, userID).Error; err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
                 "success": false,
-                "message": "Gagal memulai ujian",
+                "message": "Refresh token tidak valid",
             })
         }
-        
-        return c.JSON(fiber.Map{
-            "success": true,
-            "start_time": session.StartTime,
-            "duration": session.Duration,
-        })
-    })
-
-    // Auto-save answer endpoint
-    app.Post("/api/answers/draft", authMiddleware, func(c *fiber.Ctx) error {
-        var answer struct {
-            QuestionID uint   `json:"question_id"`
-            AnswerText string `json:"answer_text"`
-        }
-        
-        if err := c.BodyParser(&answer); err != nil {
-            return err
-        }
-        
-        userID := c.Locals("user_id").(float64)
-        
-        // Store draft answer in Redis
-        key := fmt.Sprintf("draft_answer:%d:%d", uint(userID), answer.QuestionID)
-        err := store.Storage.Set(key, []byte(answer.AnswerText), time.Hour)
+        // Status 2FA sesi dibawa ke token baru selama 2FA user masih aktif
+        pair, err := sessions.Issue(user.identityWithMFA(mfa))
         if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": "Gagal menyimpan jawaban sementara",
+                "message": "Gagal membuat token",
             })
         }
-        
         return c.JSON(fiber.Map{
             "success": true,
-            "message": "Jawaban tersimpan sementara",
+            "token": pair.AccessToken,
+            "refresh_token": pair.RefreshToken,
+            "expires_in": pair.ExpiresIn,
         })
     })
 
-    // Submit final answer endpoint with goroutine
-    app.Post("/api/answers/submit", authMiddleware, func(c *fiber.Ctx) error {
-        var answers []Answer
-        if err := c.BodyParser(&answers); err != nil {
-            return err
-        }
-        
-        userID := c.Locals("user_id").(float64)
-        
-        // Process answers in goroutine
-        go func() {
-            for _, answer := range answers {
-                answer.ParticipantID = uint(userID)
-                answer.SubmittedAt = time.Now()
-                answer.IsDraft = false
-                db.Create(&answer)
-                
-                // Clean up Redis draft
-                key := fmt.Sprintf("draft_answer:%d:%d", uint(userID), answer.QuestionID)
-                store.Storage.Delete(key)
+    // Logout endpoint: cabut access token saat ini dan refresh token-nya
+    app.Post("/api/logout", authMiddleware, func(c *fiber.Ctx) error {
+        var req struct {
+            RefreshToken string `json:"refresh_token"`
+        }
+        // Body boleh kosong jika klien tidak memegang refresh token
+        if len(c.Body()) > 0 {
+            if err := c.BodyParser(&req); err != nil {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Format data tidak valid",
+                })
             }
-        }()
-        
-        return c.JSON(fiber.Map{
-            "success": true,
-            "message": "Jawaban berhasil disubmit",
-        })
-    })
-
-    // Get exam timer endpoint
-    app.Get("/api/exam/:id/timer", authMiddleware, func(c *fiber.Ctx) error {
-        userID := c.Locals("user_id").(float64)
-        examID := c.Params("id")
-        
-        // Get session from Redis
-        sessionKey := fmt.Sprintf("exam_session:%d:%s", uint(userID), examID)
-        sessionData, err := store.Storage.Get(sessionKey)
-        if err != nil {
-            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+        }
+        claims := c.Locals("claims").(jwt.MapClaims)
+        if err := sessions.RevokeAccess(claims); err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": "Sesi ujian tidak ditemukan",
+                "message": "Gagal logout",
             })
         }
-        
-        var session ExamSession
-        if err := json.Unmarshal(sessionData, &session); err != nil {
+        if err := sessions.RevokeRefresh(req.RefreshToken); err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
-                "message": "Gagal membaca data sesi",
+                "message": "Gagal logout",
             })
         }
-        
-        remainingTime := session.Duration - int(time.Since(session.StartTime).Seconds())
-        if remainingTime < 0 {
-            remainingTime = 0
-        }
-        
         return c.JSON(fiber.Map{
             "success": true,
-            "remaining_time": remainingTime,
+            "message": "Logout berhasil",
         })
     })
 
+    // Password reset & email verification
+    registerPasswordRoutes(app, db)
+    registerEmailVerificationRoutes(app, db)
+    registerTwoFactorRoutes(app, db)
+    registerOIDCRoutes(app, db)
+
+    // ========== EXAM ENDPOINTS ==========
+    registerExamRoutes(app, db)
+
     // ========== ADMIN ENDPOINTS ==========
-    admin := app.Group("/api/admin", authMiddleware, adminMiddleware)
+    // Setiap rute admin dijaga permission masing-masing (lihat auth/permissions.go)
+    admin := app.Group("/api/admin", authMiddleware)
 
     // List all users
-    admin.Get("/users", func(c *fiber.Ctx) error {
+    admin.Get("/users", requirePermission(auth.PermUsersManage), func(c *fiber.Ctx) error {
         var users []User
         if err := db.Find(&users).Error; err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -441,7 +511,7 @@ func main() {
     })
 
     // Add user
-    admin.Post("/users", func(c *fiber.Ctx) error {
+    admin.Post("/users", requirePermission(auth.PermUsersManage), func(c *fiber.Ctx) error {
         var req struct {
             Name     string `json:"name"`
             Email    string `json:"email"`
@@ -460,6 +530,9 @@ func main() {
                 "message": "Nama, email, password, dan role wajib diisi",
             })
         }
+        if !auth.ValidRole(req.Role) {
+            return invalidRole(c)
+        }
         // Cek apakah user sudah ada
         var user User
         if err := db.Where("email = ?", req.Email).First(&user).Error; err == nil {
@@ -468,7 +541,14 @@ func main() {
                 "message": "Email sudah terdaftar",
             })
         }
-        user = User{Name: req.Name, Email: req.Email, Password: req.Password, Role: req.Role}
+        hash, err := passwords.Hash(req.Password)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menambah user",
+            })
+        }
+        user = User{Name: req.Name, Email: req.Email, Password: hash, Role: auth.NormalizeRole(req.Role)}
         if err := db.Create(&user).Error; err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
@@ -482,7 +562,7 @@ func main() {
     })
 
     // Edit user
-    admin.Put("/users/:id", func(c *fiber.Ctx) error {
+    admin.Put("/users/:id", requirePermission(auth.PermUsersManage), func(c *fiber.Ctx) error {
         id := c.Params("id")
         var user User
         if err := db.First(&user, id).Error; err != nil {
@@ -503,65 +583,139 @@ func main() {
                 "message": "Format data tidak valid",
             })
         }
+        if !auth.ValidRole(req.Role) {
+            return invalidRole(c)
+        }
+        previousRole := user.Role
         user.Name = req.Name
         user.Email = req.Email
-        user.Password = req.Password
-        user.Role = req.Role
+        user.Role = auth.NormalizeRole(req.Role)
+        // Password hanya diganti jika diisi
+        if req.Password != "" {
+            hash, err := passwords.Hash(req.Password)
+            if err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Gagal update user",
+                })
+            }
+            user.Password = hash
+        }
         if err := db.Save(&user).Error; err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
                 "message": "Gagal update user",
             })
         }
+        // Password atau role berubah: token lama tidak boleh dipakai lagi
+        if req.Password != "" || user.Role != previousRole {
+            if err := sessions.RevokeAllForUser(user.ID); err != nil {
+                log.Printf("Gagal mencabut sesi user %d: %v", user.ID, err)
+            }
+        }
         return c.JSON(fiber.Map{
             "success": true,
             "message": "User berhasil diupdate",
         })
     })
 
+    // Revoke all sessions for a user
+    admin.Post("/users/:id/revoke-sessions", requirePermission(auth.PermUsersManage), func(c *fiber.Ctx) error {
+        var user User
+        if err := db.First(&user, c.Params("id")).Error; err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User tidak ditemukan",
+            })
+        }
+        if err := sessions.RevokeAllForUser(user.ID); err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mencabut sesi user",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Semua sesi user berhasil dicabut",
+        })
+    })
+
     // Delete user
-    admin.Delete("/users/:id", func(c *fiber.Ctx) error {
+    admin.Delete("/users/:id", requirePermission(auth.PermUsersManage), func(c *fiber.Ctx) error {
         id := c.Params("id")
-        if err := db.Delete(&User{}, id).Error; err != nil {
+        var user User
+        if err := db.First(&user, id).Error; err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User tidak ditemukan",
+            })
+        }
+        err := db.Transaction(func(tx *gorm.DB) error {
+            if err := tx.Where("user_id = ?", user.ID).Delete(&models.GroupMember{}).Error; err != nil {
+                return err
+            }
+            return tx.Delete(&user).Error
+        })
+        if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
                 "message": "Gagal menghapus user",
             })
         }
+        if err := sessions.RevokeAllForUser(user.ID); err != nil {
+            log.Printf("Gagal mencabut sesi user %d: %v", user.ID, err)
+        }
         return c.JSON(fiber.Map{
             "success": true,
             "message": "User berhasil dihapus",
         })
     })
 
+    // Email verification management
+    registerAdminVerificationRoutes(admin, db)
+    registerAdminTwoFactorRoutes(admin, db)
+
+    // Exam management
+    registerAdminExamRoutes(admin, db)
+    registerAdminGroupRoutes(admin, db)
+    registerAdminGradingRoutes(admin, db)
+
     // List all questions
-    admin.Get("/questions", func(c *fiber.Ctx) error {
-        var questions []Question
-        if err := db.Find(&questions).Error; err != nil {
+    admin.Get("/questions", requirePermission(auth.PermQuestionsManage), func(c *fiber.Ctx) error {
+        var questions []models.Question
+        err := db.Where("exam_id IN (?)", managedExams(c, db).Select("exams.id")).Order("id").Find(&questions).Error
+        if err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
                 "message": "Gagal mengambil soal",
             })
         }
-        return c.JSON(questions)
+        result := make([]AdminQuestion, 0, len(questions))
+        for _, q := range questions {
+            result = append(result, toAdminQuestion(q))
+        }
+        return c.JSON(result)
     })
 
     // Add question
-    admin.Post("/questions", func(c *fiber.Ctx) error {
-        var q Question
-        if err := c.BodyParser(&q); err != nil {
+    admin.Post("/questions", requirePermission(auth.PermQuestionsManage), func(c *fiber.Ctx) error {
+        var req AdminQuestion
+        if err := c.BodyParser(&req); err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Format data tidak valid",
             })
         }
-        if q.Type == "" {
-            q.Type = "pilihan_ganda"
+        var q models.Question
+        req.applyTo(&q)
+        // Guru hanya boleh menambah soal ke ujian miliknya
+        if _, err := findManagedExam(c, db, q.ExamID); err != nil {
+            return examNotFound(c)
         }
-        if q.Type == "pilihan_ganda" && len(q.Options) < 2 {
+        if err := q.Validate(); err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": "Opsi pilihan ganda minimal 2!",
+                "message": err.Error(),
             })
         }
         if err := db.Create(&q).Error; err != nil {
@@ -577,35 +731,30 @@ func main() {
     })
 
     // Edit question
-    admin.Put("/questions/:id", func(c *fiber.Ctx) error {
-        id := c.Params("id")
-        var q Question
-        if err := db.First(&q, id).Error; err != nil {
+    admin.Put("/questions/:id", requirePermission(auth.PermQuestionsManage), func(c *fiber.Ctx) error {
+        q, err := findManagedQuestion(c, db, c.Params("id"))
+        if err != nil {
             return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
                 "success": false,
                 "message": "Soal tidak ditemukan",
             })
         }
-        var update Question
+        var update AdminQuestion
         if err := c.BodyParser(&update); err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
                 "message": "Format data tidak valid",
             })
         }
-        q.ExamID = update.ExamID
-        q.QuestionText = update.QuestionText
-        q.CorrectAnswer = update.CorrectAnswer
-        q.Weight = update.Weight
-        q.Type = update.Type
-        q.Options = update.Options
-        if q.Type == "" {
-            q.Type = "pilihan_ganda"
+        update.applyTo(&q)
+        // Soal tidak boleh dipindah ke ujian milik guru lain
+        if _, err := findManagedExam(c, db, q.ExamID); err != nil {
+            return examNotFound(c)
         }
-        if q.Type == "pilihan_ganda" && len(q.Options) < 2 {
+        if err := q.Validate(); err != nil {
             return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
                 "success": false,
-                "message": "Opsi pilihan ganda minimal 2!",
+                "message": err.Error(),
             })
         }
         if err := db.Save(&q).Error; err != nil {
@@ -621,9 +770,15 @@ func main() {
     })
 
     // Delete question
-    admin.Delete("/questions/:id", func(c *fiber.Ctx) error {
-        id := c.Params("id")
-        if err := db.Delete(&Question{}, id).Error; err != nil {
+    admin.Delete("/questions/:id", requirePermission(auth.PermQuestionsManage), func(c *fiber.Ctx) error {
+        q, err := findManagedQuestion(c, db, c.Params("id"))
+        if err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "Soal tidak ditemukan",
+            })
+        }
+        if err := db.Delete(&q).Error; err != nil {
             return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                 "success": false,
                 "message": "Gagal menghapus soal",
@@ -636,20 +791,31 @@ func main() {
     })
 
     // Export hasil ujian (CSV)
-    admin.Get("/export", func(c *fiber.Ctx) error {
-        var answers []Answer
-        if err := db.Find(&answers).Error; err != nil {
+    admin.Get("/export", requirePermission(auth.PermResultsExport), func(c *fiber.Ctx) error {
+        var answers []models.Answer
+        examIDs := managedExams(c, db).Select("exams.id")
+        err := db.Where("question_id IN (?)", db.Model(&models.Question{}).Select("id").Where("exam_id IN (?)", examIDs)).
+            Order("id").Find(&answers).Error
+        if err != nil {
             return c.Status(fiber.StatusInternalServerError).SendString("Gagal mengambil data jawaban")
         }
-        csv := "ID,ParticipantID,QuestionID,AnswerText,SubmittedAt,IsDraft\n"
+        csv := "ID,AttemptID,ParticipantID,QuestionID,AnswerText,SubmittedAt,IsDraft\n"
         for _, a := range answers {
-            csv += fmt.Sprintf("%d,%d,%d,%s,%s,%t\n", a.ID, a.ParticipantID, a.QuestionID, a.AnswerText, a.SubmittedAt.Format(time.RFC3339), a.IsDraft)
+            csv += fmt.Sprintf("%d,%d,%d,%d,%s,%s,%t\n", a.ID, a.AttemptID, a.ParticipantID, a.QuestionID, a.AnswerText, a.SubmittedAt.Format(time.RFC3339), a.IsDraft)
         }
         c.Set("Content-Type", "text/csv")
         c.Set("Content-Disposition", "attachment; filename=hasil_ujian.csv")
         return c.SendString(csv)
     })
 
+    // Worker latar belakang cukup satu per server: proses child Prefork tidak menjalankannya
+    if !fiber.IsChild() {
+        startAutoSubmitWorker(db, config.AutoSubmitInterval, config.DeadlineGrace)
+        if hybrid, ok := draftStore.(*drafts.HybridStore); ok {
+            hybrid.StartWriteBehind(config.DraftFlushInterval, log.Printf)
+        }
+    }
+
     // Development/Production mode switch
     if os.Getenv("GO_ENV") == "production" {
         // Production mode with SSL
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Answer model
+type Answer struct {
+    ID           uint      `gorm:"primaryKey" json:"id"`
+    AttemptID    uint      `gorm:"uniqueIndex:idx_answer_attempt_question" json:"attempt_id"`
+    ParticipantID uint     `json:"participant_id"`
+    QuestionID   uint      `gorm:"uniqueIndex:idx_answer_attempt_question" json:"question_id"`
+    AnswerText   string    `json:"answer_text"`
+    SubmittedAt  time.Time `json:"submitted_at"`
+    IsDraft      bool      `json:"is_draft"`
+}
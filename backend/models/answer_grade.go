@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Status penilaian attempt dan nilai akhir peserta
+const (
+    ResultPending = "pending" // masih ada jawaban yang menunggu dinilai manual
+    ResultGraded  = "graded"
+)
+
+// AnswerGrade adalah nilai manual dari guru untuk satu jawaban final
+// (soal esai atau jawaban singkat)
+type AnswerGrade struct {
+    ID       uint    `gorm:"primaryKey" json:"id"`
+    AnswerID uint    `gorm:"uniqueIndex;not null" json:"answer_id"`
+    GraderID uint    `gorm:"not null" json:"grader_id"`
+    Points   float64 `json:"points"` // antara 0 dan bobot soal
+    // Poin per kriteria rubrik, urut sesuai Question.Rubric
+    RubricScores []float64 `gorm:"serializer:json;type:jsonb" json:"rubric_scores"`
+    Comment      string    `json:"comment"`
+    GradedAt     time.Time `json:"graded_at"`
+}
@@ -0,0 +1,229 @@
+package models
+
+import (
+    "errors"
+    "fmt"
+    "math/rand"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// Aturan nilai parsial soal pilihan ganda kompleks, menjodohkan dan mengurutkan
+const (
+    CreditAllOrNothing    = "all_or_nothing"    // nilai penuh hanya jika jawaban persis sama
+    CreditPartial         = "partial"           // porsi pilihan benar, 0 jika ada pilihan salah
+    CreditRightMinusWrong = "right_minus_wrong" // (benar - salah) / jumlah kunci, minimal 0
+    CreditPerPair         = "per_pair"          // porsi pasangan (atau posisi urutan) yang benar
+)
+
+// Mode toleransi soal numerik
+const (
+    ToleranceAbsolute = "absolute"
+    ToleranceRelative = "relative"
+)
+
+// AnswerKey adalah kunci jawaban terstruktur untuk tipe soal yang tidak bisa
+// diwakili satu string CorrectAnswer. Field yang dipakai bergantung tipe soal;
+// CorrectAnswer tetap diisi sebagai ringkasan untuk tampilan admin.
+type AnswerKey struct {
+    // pilihan_ganda_kompleks: opsi yang benar dan aturan nilai parsial
+    Choices       []string `json:"choices,omitempty"`
+    PartialCredit string   `json:"partial_credit,omitempty"`
+
+    // benar_salah
+    Value *bool `json:"value,omitempty"`
+
+    // numerik: jawaban dan toleransinya (absolute atau relative, mis. 0.01 = 1%)
+    Number        *float64 `json:"number,omitempty"`
+    Tolerance     float64  `json:"tolerance,omitempty"`
+    ToleranceMode string   `json:"tolerance_mode,omitempty"`
+
+    // isian: daftar jawaban yang diterima, dibandingkan tanpa membedakan
+    // huruf besar kecuali CaseSensitive, atau sebagai regex jika Regex
+    Accepted      []string `json:"accepted,omitempty"`
+    CaseSensitive bool     `json:"case_sensitive,omitempty"`
+    Regex         bool     `json:"regex,omitempty"`
+
+    // menjodohkan: pasangan yang benar; mengurutkan: urutan yang benar.
+    // Keduanya memakai PartialCredit all_or_nothing atau per_pair.
+    Pairs    []MatchPair `json:"pairs,omitempty"`
+    Sequence []string    `json:"sequence,omitempty"`
+}
+
+// MatchPair adalah satu pasangan soal menjodohkan
+type MatchPair struct {
+    Left  string `json:"left"`
+    Right string `json:"right"`
+}
+
+// TrueFalseOptions adalah opsi yang ditampilkan ke peserta untuk soal benar_salah
+var TrueFalseOptions = []string{"Benar", "Salah"}
+
+func validateMultipleChoice(q *Question) error {
+    if len(q.Options) < 2 {
+        return errors.New("Opsi pilihan ganda kompleks minimal 2!")
+    }
+    if q.AnswerKey == nil || len(q.AnswerKey.Choices) == 0 {
+        return errors.New("Pilih minimal satu opsi yang benar")
+    }
+    for _, choice := range q.AnswerKey.Choices {
+        if !contains(q.Options, choice) {
+            return fmt.Errorf("Kunci %q tidak ada di daftar opsi", choice)
+        }
+    }
+    switch q.AnswerKey.PartialCredit {
+    case "":
+        q.AnswerKey.PartialCredit = CreditAllOrNothing
+    case CreditAllOrNothing, CreditPartial, CreditRightMinusWrong:
+    default:
+        return errors.New("partial_credit harus all_or_nothing, partial, atau right_minus_wrong")
+    }
+    q.CorrectAnswer = strings.Join(q.AnswerKey.Choices, ", ")
+    return nil
+}
+
+func validateTrueFalse(q *Question) error {
+    if q.AnswerKey == nil || q.AnswerKey.Value == nil {
+        return errors.New("Kunci jawaban benar/salah wajib diisi")
+    }
+    q.Options = TrueFalseOptions
+    q.CorrectAnswer = TrueFalseOptions[1]
+    if *q.AnswerKey.Value {
+        q.CorrectAnswer = TrueFalseOptions[0]
+    }
+    return nil
+}
+
+func validateNumeric(q *Question) error {
+    if q.AnswerKey == nil || q.AnswerKey.Number == nil {
+        return errors.New("Kunci jawaban numerik wajib diisi")
+    }
+    if q.AnswerKey.Tolerance < 0 {
+        return errors.New("Toleransi tidak boleh negatif")
+    }
+    switch q.AnswerKey.ToleranceMode {
+    case "":
+        q.AnswerKey.ToleranceMode = ToleranceAbsolute
+    case ToleranceAbsolute, ToleranceRelative:
+    default:
+        return errors.New("tolerance_mode harus absolute atau relative")
+    }
+    q.CorrectAnswer = strconv.FormatFloat(*q.AnswerKey.Number, 'f', -1, 64)
+    q.Options = nil
+    return nil
+}
+
+func validateFillBlank(q *Question) error {
+    // Soal isian lama hanya memiliki correct_answer
+    if (q.AnswerKey == nil || len(q.AnswerKey.Accepted) == 0) && strings.TrimSpace(q.CorrectAnswer) != "" {
+        if q.AnswerKey == nil {
+            q.AnswerKey = &AnswerKey{}
+        }
+        q.AnswerKey.Accepted = []string{q.CorrectAnswer}
+    }
+    if q.AnswerKey == nil || len(q.AnswerKey.Accepted) == 0 {
+        return errors.New("Minimal satu jawaban isian yang diterima wajib diisi")
+    }
+    for _, accepted := range q.AnswerKey.Accepted {
+        if strings.TrimSpace(accepted) == "" {
+            return errors.New("Jawaban isian yang diterima tidak boleh kosong")
+        }
+        if q.AnswerKey.Regex {
+            if _, err := regexp.Compile(accepted); err != nil {
+                return fmt.Errorf("Regex %q tidak valid: %v", accepted, err)
+            }
+        }
+    }
+    q.CorrectAnswer = q.AnswerKey.Accepted[0]
+    q.Options = nil
+    return nil
+}
+
+func validateMatching(q *Question) error {
+    if q.AnswerKey == nil || len(q.AnswerKey.Pairs) < 2 {
+        return errors.New("Soal menjodohkan minimal memiliki 2 pasangan")
+    }
+    prompts := make([]string, 0, len(q.AnswerKey.Pairs))
+    rights := make([]string, 0, len(q.AnswerKey.Pairs))
+    summary := make([]string, 0, len(q.AnswerKey.Pairs))
+    for i, pair := range q.AnswerKey.Pairs {
+        if strings.TrimSpace(pair.Left) == "" || strings.TrimSpace(pair.Right) == "" {
+            return fmt.Errorf("Pasangan ke-%d harus memiliki sisi kiri dan kanan", i+1)
+        }
+        if contains(prompts, pair.Left) {
+            return fmt.Errorf("Pernyataan %q muncul lebih dari sekali", pair.Left)
+        }
+        prompts = append(prompts, pair.Left)
+        // Satu jawaban boleh menjadi pasangan beberapa pernyataan
+        if !contains(rights, pair.Right) {
+            rights = append(rights, pair.Right)
+        }
+        summary = append(summary, pair.Left+" = "+pair.Right)
+    }
+    if err := validatePairCredit(q.AnswerKey); err != nil {
+        return err
+    }
+    q.Prompts = prompts
+    q.Options = shuffled(rights)
+    q.CorrectAnswer = strings.Join(summary, "; ")
+    return nil
+}
+
+func validateOrdering(q *Question) error {
+    if q.AnswerKey == nil || len(q.AnswerKey.Sequence) < 2 {
+        return errors.New("Soal mengurutkan minimal memiliki 2 item")
+    }
+    for i, item := range q.AnswerKey.Sequence {
+        if strings.TrimSpace(item) == "" {
+            return fmt.Errorf("Item urutan ke-%d tidak boleh kosong", i+1)
+        }
+        if contains(q.AnswerKey.Sequence[:i], item) {
+            return fmt.Errorf("Item %q muncul lebih dari sekali", item)
+        }
+    }
+    if err := validatePairCredit(q.AnswerKey); err != nil {
+        return err
+    }
+    q.Options = shuffled(q.AnswerKey.Sequence)
+    q.CorrectAnswer = strings.Join(q.AnswerKey.Sequence, ", ")
+    return nil
+}
+
+func validatePairCredit(key *AnswerKey) error {
+    switch key.PartialCredit {
+    case "":
+        key.PartialCredit = CreditAllOrNothing
+    case CreditAllOrNothing, CreditPerPair:
+    default:
+        return errors.New("partial_credit harus all_or_nothing atau per_pair")
+    }
+    return nil
+}
+
+// shuffled mengacak salinan items untuk ditampilkan ke peserta. Hasil yang
+// kebetulan sama dengan urutan kunci digeser satu posisi agar kunci tidak bocor.
+func shuffled(items []string) []string {
+    out := append([]string(nil), items...)
+    rand.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+    same := true
+    for i := range out {
+        if out[i] != items[i] {
+            same = false
+            break
+        }
+    }
+    if same && len(out) > 1 {
+        out = append(out[1:], out[0])
+    }
+    return out
+}
+
+func contains(list []string, s string) bool {
+    for _, item := range list {
+        if item == s {
+            return true
+        }
+    }
+    return false
+}
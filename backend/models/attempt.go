@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Status attempt ujian
+const (
+    AttemptInProgress = "in_progress"
+    AttemptSubmitted  = "submitted"
+    AttemptExpired    = "expired" // dikumpulkan otomatis setelah deadline
+)
+
+// FinishedAttemptStatuses adalah status attempt yang sudah dikumpulkan dan dinilai
+var FinishedAttemptStatuses = []string{AttemptSubmitted, AttemptExpired}
+
+// Attempt adalah satu kali pengerjaan ujian oleh peserta, dibuat saat
+// peserta memanggil /api/exam/:id/start
+type Attempt struct {
+    ID             uint       `gorm:"primaryKey" json:"id"`
+    ExamID         uint       `gorm:"index:idx_attempt_exam_participant;not null" json:"exam_id"`
+    ParticipantID  uint       `gorm:"index:idx_attempt_exam_participant;not null" json:"participant_id"`
+    Status         string     `gorm:"size:20;not null;default:in_progress" json:"status"`
+    StartedAt      time.Time  `json:"started_at"`
+    Deadline       time.Time  `json:"deadline"`
+    SubmittedAt    *time.Time `json:"submitted_at"`
+    IdempotencyKey string     `gorm:"size:255" json:"-"`
+    SavedCount     int        `json:"saved_count"`
+    AutoSubmitted  bool       `gorm:"not null;default:false" json:"auto_submitted"` // dikumpulkan worker setelah deadline
+    Score          float64    `json:"score"`
+    MaxScore       float64    `json:"max_score"`
+    // pending selama masih ada jawaban esai/jawaban singkat yang belum dinilai
+    ResultStatus   string     `gorm:"size:10;not null;default:graded" json:"result_status"`
+    CreatedAt      time.Time  `json:"created_at"`
+    UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -1,9 +1,104 @@
 package models
 
+import (
+    "errors"
+    "strings"
+    "time"
+)
+
+// Status ketersediaan ujian berdasarkan opens_at dan closes_at
+const (
+    ExamUpcoming = "upcoming"
+    ExamOpen     = "open"
+    ExamClosed   = "closed"
+)
+
+// Kebijakan nilai akhir jika peserta mengerjakan lebih dari satu attempt
+const (
+    GradingBest    = "best"
+    GradingLast    = "last"
+    GradingAverage = "average"
+)
+
 type Exam struct {
-    ID          uint   `json:"id"`
-    Title       string `json:"title"`
+    ID          uint   `gorm:"primaryKey" json:"id"`
+    Title       string `gorm:"not null" json:"title"`
     Description string `json:"description"`
-    Duration    int    `json:"duration"`
-    CreatedAt   time.Time
-}
\ No newline at end of file
+    Duration    int    `json:"duration"` // dalam detik
+    OwnerID     uint   `gorm:"index" json:"owner_id"` // guru pembuat ujian; 0 untuk ujian lama (hanya admin)
+    // Jendela waktu pengerjaan; nil berarti tidak dibatasi di sisi tersebut
+    OpensAt  *time.Time `gorm:"type:timestamptz" json:"opens_at"`
+    ClosesAt *time.Time `gorm:"type:timestamptz" json:"closes_at"`
+    // Jumlah attempt maksimum per peserta; 0 berarti tidak dibatasi. Default
+    // kolom harus 0 karena GORM mengganti nilai nol dengan default saat insert;
+    // ujian baru dari API tetap mendapat 1 jika max_attempts tidak diisi.
+    MaxAttempts   int       `gorm:"not null;default:0" json:"max_attempts"`
+    GradingPolicy string    `gorm:"size:10;not null;default:last" json:"grading_policy"`
+    CreatedAt     time.Time `json:"created_at"`
+}
+
+// Validate memeriksa field wajib sebelum ujian disimpan
+func (e *Exam) Validate() error {
+    e.Title = strings.TrimSpace(e.Title)
+    if e.Title == "" {
+        return errors.New("Judul ujian wajib diisi")
+    }
+    if e.Duration <= 0 {
+        return errors.New("Durasi ujian harus lebih dari 0 detik")
+    }
+    if e.MaxAttempts < 0 {
+        return errors.New("Jumlah attempt maksimum tidak boleh negatif")
+    }
+    switch e.GradingPolicy {
+    case "":
+        e.GradingPolicy = GradingLast
+    case GradingBest, GradingLast, GradingAverage:
+    default:
+        return errors.New("Kebijakan nilai harus best, last, atau average")
+    }
+    if e.OpensAt != nil && e.ClosesAt != nil && !e.ClosesAt.After(*e.OpensAt) {
+        return errors.New("Waktu tutup ujian harus setelah waktu buka")
+    }
+    return nil
+}
+
+// StatusAt mengembalikan status ketersediaan ujian pada waktu now
+func (e *Exam) StatusAt(now time.Time) string {
+    if e.OpensAt != nil && now.Before(*e.OpensAt) {
+        return ExamUpcoming
+    }
+    if e.ClosesAt != nil && !now.Before(*e.ClosesAt) {
+        return ExamClosed
+    }
+    return ExamOpen
+}
+
+// DeadlineFor menghitung batas waktu attempt yang dimulai pada start dengan
+// durasi tertentu (detik). Batas waktu tidak pernah melewati closes_at.
+func (e *Exam) DeadlineFor(start time.Time, duration int) time.Time {
+    deadline := start.Add(time.Duration(duration) * time.Second)
+    if e.ClosesAt != nil && deadline.After(*e.ClosesAt) {
+        return *e.ClosesAt
+    }
+    return deadline
+}
+
+// ExamOverride menyimpan penyesuaian waktu per peserta (mis. akomodasi tambahan waktu)
+type ExamOverride struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    ExamID    uint      `gorm:"uniqueIndex:idx_exam_override_user;not null" json:"exam_id"`
+    UserID    uint      `gorm:"uniqueIndex:idx_exam_override_user;not null" json:"user_id"`
+    ExtraTime int       `json:"extra_time"` // dalam detik
+    Note      string    `json:"note"`
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DurationFor menghitung durasi ujian (detik) untuk peserta tertentu.
+// Penyesuaian waktu hanya bisa menambah, tidak pernah memotong durasi dasar.
+func (e *Exam) DurationFor(override *ExamOverride) int {
+    if override == nil || override.ExtraTime < 0 {
+        return e.Duration
+    }
+    return e.Duration + override.ExtraTime
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+    "errors"
+    "strings"
+    "time"
+)
+
+// Group adalah kelas atau kelompok peserta. Ujian ditugaskan ke group dan
+// hanya anggota group yang bisa melihat dan mengerjakan ujian tersebut.
+type Group struct {
+    ID          uint      `gorm:"primaryKey" json:"id"`
+    Name        string    `gorm:"not null" json:"name"`
+    Description string    `json:"description"`
+    OwnerID     uint      `gorm:"index" json:"owner_id"` // user (guru/admin) yang membuat group
+    CreatedAt   time.Time `json:"created_at"`
+}
+
+// Validate memeriksa field wajib sebelum group disimpan
+func (g *Group) Validate() error {
+    g.Name = strings.TrimSpace(g.Name)
+    if g.Name == "" {
+        return errors.New("Nama group wajib diisi")
+    }
+    return nil
+}
+
+// GroupMember adalah keanggotaan (enrollment) peserta di satu group
+type GroupMember struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    GroupID   uint      `gorm:"uniqueIndex:idx_group_member;not null" json:"group_id"`
+    UserID    uint      `gorm:"uniqueIndex:idx_group_member;index;not null" json:"user_id"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// ExamGroup menugaskan satu ujian ke satu group
+type ExamGroup struct {
+    ID      uint `gorm:"primaryKey" json:"id"`
+    ExamID  uint `gorm:"uniqueIndex:idx_exam_group;not null" json:"exam_id"`
+    GroupID uint `gorm:"uniqueIndex:idx_exam_group;index;not null" json:"group_id"`
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// Tipe soal
+const (
+    QuestionSingleChoice   = "pilihan_ganda"
+    QuestionMultipleChoice = "pilihan_ganda_kompleks" // lebih dari satu jawaban benar
+    QuestionTrueFalse      = "benar_salah"
+    QuestionNumeric        = "numerik"
+    QuestionFillBlank      = "isian"
+    QuestionMatching       = "menjodohkan"
+    QuestionOrdering       = "mengurutkan"
+    QuestionEssay          = "esai"            // dinilai manual oleh guru
+    QuestionShortAnswer    = "jawaban_singkat" // dinilai manual oleh guru
+)
+
+// RubricCriterion adalah satu kriteria penilaian soal yang dinilai manual
+type RubricCriterion struct {
+    Criterion string  `json:"criterion"`
+    Points    float64 `json:"points"` // nilai maksimum kriteria
+}
+
+// Question model
+type Question struct {
+    ID            uint     `gorm:"primaryKey" json:"id"`
+    ExamID        uint     `json:"exam_id"`
+    QuestionText  string   `json:"question_text"`
+    CorrectAnswer string   `json:"-"` // hanya diekspos lewat AdminQuestion
+    Weight        int      `json:"-" gorm:"default:1"`
+    Type          string   `json:"type" gorm:"default:'pilihan_ganda'"`
+    Options       []string `gorm:"serializer:json;type:jsonb" json:"options"`
+    // Pernyataan kiri soal menjodohkan; pasangannya diacak di Options
+    Prompts []string `gorm:"serializer:json;type:jsonb" json:"prompts,omitempty"`
+    // Kunci jawaban terstruktur untuk tipe selain pilihan_ganda
+    AnswerKey *AnswerKey `gorm:"serializer:json;type:jsonb" json:"-"`
+    // Rubrik untuk soal yang dinilai manual; kosong berarti guru memberi poin langsung
+    Rubric []RubricCriterion `gorm:"serializer:json;type:jsonb" json:"-"`
+}
+
+// Validate memeriksa isian soal sesuai tipenya sebelum disimpan
+func (q *Question) Validate() error {
+    if strings.TrimSpace(q.QuestionText) == "" {
+        return errors.New("Pertanyaan wajib diisi")
+    }
+    if q.Type == "" {
+        q.Type = QuestionSingleChoice
+    }
+    if q.Type != QuestionEssay && q.Type != QuestionShortAnswer {
+        q.Rubric = nil
+    }
+    if q.Type != QuestionMatching {
+        q.Prompts = nil
+    }
+    switch q.Type {
+    case QuestionSingleChoice:
+        if len(q.Options) < 2 {
+            return errors.New("Opsi pilihan ganda minimal 2!")
+        }
+        if strings.TrimSpace(q.CorrectAnswer) == "" {
+            return errors.New("Jawaban benar wajib diisi")
+        }
+        if !contains(q.Options, q.CorrectAnswer) {
+            return errors.New("Jawaban benar harus salah satu dari opsi")
+        }
+        q.AnswerKey = nil
+    case QuestionMultipleChoice:
+        return validateMultipleChoice(q)
+    case QuestionTrueFalse:
+        return validateTrueFalse(q)
+    case QuestionNumeric:
+        return validateNumeric(q)
+    case QuestionFillBlank:
+        return validateFillBlank(q)
+    case QuestionMatching:
+        return validateMatching(q)
+    case QuestionOrdering:
+        return validateOrdering(q)
+    case QuestionEssay, QuestionShortAnswer:
+        q.Options = nil
+        q.CorrectAnswer = ""
+        q.AnswerKey = nil
+        for i, c := range q.Rubric {
+            if strings.TrimSpace(c.Criterion) == "" || c.Points <= 0 {
+                return fmt.Errorf("Kriteria rubrik ke-%d harus memiliki nama dan poin lebih dari 0", i+1)
+            }
+        }
+    default:
+        return fmt.Errorf("Tipe soal %q tidak dikenal", q.Type)
+    }
+    return nil
+}
+
+// ManualGraded menandai tipe soal yang tidak bisa dinilai otomatis
+func (q *Question) ManualGraded() bool {
+    return q.Type == QuestionEssay || q.Type == QuestionShortAnswer
+}
+
+// RubricMax adalah jumlah poin maksimum seluruh kriteria rubrik
+func (q *Question) RubricMax() float64 {
+    var total float64
+    for _, c := range q.Rubric {
+        total += c.Points
+    }
+    return total
+}
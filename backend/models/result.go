@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Result menyimpan skor akhir peserta untuk satu ujian, dihitung dari
+// attempt yang sudah dikumpulkan sesuai kebijakan nilai ujian.
+// Skor selalu dihitung di server oleh package grading.
+type Result struct {
+    ID            uint      `gorm:"primaryKey" json:"id"`
+    ExamID        uint      `gorm:"uniqueIndex:idx_result_exam_participant;not null" json:"exam_id"`
+    ParticipantID uint      `gorm:"uniqueIndex:idx_result_exam_participant;not null" json:"participant_id"`
+    Score         float64   `json:"score"`
+    MaxScore      float64   `json:"max_score"`
+    Attempts      int       `json:"attempts"` // jumlah attempt yang dinilai
+    Status        string    `gorm:"size:10;not null;default:graded" json:"status"` // pending jika ada attempt yang belum selesai dinilai
+    GradedAt      time.Time `json:"graded_at"`
+}
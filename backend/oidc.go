@@ -0,0 +1,266 @@
+package main
+
+import (
+    "crypto/sha256"
+    "crypto/subtle"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "log"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+    "online-exam-app-backend/auth"
+)
+
+// oidcLoginState disimpan di Redis selama user berada di halaman identity provider
+type oidcLoginState struct {
+    Nonce        string `json:"nonce"`
+    CodeVerifier string `json:"code_verifier"`
+}
+
+var errOIDCEmailTaken = errors.New("email sudah dipakai akun lain")
+
+// oidcStateCookie mengikat state login SSO ke browser yang memulainya,
+// sehingga URL callback milik orang lain tidak bisa dipakai untuk membuat
+// korban login sebagai akun penyerang (login CSRF)
+const (
+    oidcStateCookie = "oidc_state"
+    oidcStateTTL    = 10 * time.Minute
+)
+
+func oidcStateHash(state string) string {
+    sum := sha256.Sum256([]byte(state))
+    return hex.EncodeToString(sum[:])
+}
+
+func setOIDCStateCookie(c *fiber.Ctx, state string) {
+    c.Cookie(&fiber.Cookie{
+        Name:     oidcStateCookie,
+        Value:    oidcStateHash(state),
+        Path:     "/api/auth/oidc",
+        Expires:  time.Now().Add(oidcStateTTL),
+        HTTPOnly: true,
+        Secure:   c.Protocol() == "https",
+        SameSite: fiber.CookieSameSiteLaxMode,
+    })
+}
+
+func clearOIDCStateCookie(c *fiber.Ctx) {
+    c.Cookie(&fiber.Cookie{
+        Name:     oidcStateCookie,
+        Value:    "",
+        Path:     "/api/auth/oidc",
+        Expires:  time.Unix(0, 0),
+        HTTPOnly: true,
+        Secure:   c.Protocol() == "https",
+        SameSite: fiber.CookieSameSiteLaxMode,
+    })
+}
+
+// oidcStateMatches memeriksa state di query callback dengan cookie browser
+func oidcStateMatches(c *fiber.Ctx) bool {
+    cookie, state := c.Cookies(oidcStateCookie), c.Query("state")
+    if cookie == "" || state == "" {
+        return false
+    }
+    return subtle.ConstantTimeCompare([]byte(cookie), []byte(oidcStateHash(state))) == 1
+}
+
+// oidcRoleFor memetakan grup dari identity provider ke role aplikasi memakai
+// OIDC_ROLE_MAPPING ("grup=role,..."). Entri pertama yang cocok dipakai.
+func oidcRoleFor(groups []string) string {
+    member := make(map[string]bool, len(groups))
+    for _, g := range groups {
+        member[g] = true
+    }
+    for _, entry := range strings.Split(config.OIDCRoleMapping, ",") {
+        parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+        if len(parts) == 2 && member[strings.TrimSpace(parts[0])] && auth.ValidRole(parts[1]) {
+            return auth.NormalizeRole(parts[1])
+        }
+    }
+    return ""
+}
+
+// upsertOIDCUser mencari user berdasarkan subject SSO, lalu berdasarkan email
+// yang sudah diverifikasi provider, dan membuat user baru jika belum ada
+func upsertOIDCUser(db *gorm.DB, id *auth.OIDCIdentity) (User, error) {
+    var user User
+    mappedRole := oidcRoleFor(id.Groups)
+
+    err := db.Where("oidc_subject = ?", id.Subject).First(&user).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) && id.Email != "" {
+        err = db.Where("email = ?", id.Email).First(&user).Error
+        if err == nil {
+            // Akun lokal hanya ditautkan jika provider menjamin pemilik email
+            if !id.EmailVerified || user.OIDCSubject != nil {
+                return user, errOIDCEmailTaken
+            }
+            user.OIDCSubject = &id.Subject
+            if err := db.Model(&user).Update("oidc_subject", id.Subject).Error; err != nil {
+                return user, err
+            }
+        }
+    }
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        if id.Email == "" {
+            return user, errors.New("provider tidak mengirim klaim email")
+        }
+        // User baru dibuat saat login pertama (just-in-time) tanpa password lokal
+        user = User{
+            Name:          id.Name,
+            Email:         id.Email,
+            Role:          mappedRole,
+            EmailVerified: id.EmailVerified,
+            OIDCSubject:   &id.Subject,
+        }
+        if user.Role == "" {
+            user.Role = auth.NormalizeRole(config.OIDCDefaultRole)
+        }
+        err = db.Select("Name", "Email", "Password", "Role", "EmailVerified", "OIDCSubject").Create(&user).Error
+        return user, err
+    }
+    if err != nil {
+        return user, err
+    }
+
+    // Role dan status email mengikuti provider setiap kali login
+    updates := map[string]interface{}{}
+    if mappedRole != "" && mappedRole != user.Role {
+        updates["role"] = mappedRole
+        user.Role = mappedRole
+    }
+    if id.EmailVerified && !user.EmailVerified {
+        updates["email_verified"] = true
+        user.EmailVerified = true
+    }
+    if len(updates) > 0 {
+        err = db.Model(&user).Updates(updates).Error
+    }
+    return user, err
+}
+
+// registerOIDCRoutes mendaftarkan login SSO OpenID Connect (authorization
+// code + PKCE). Rute tidak didaftarkan jika OIDC_ISSUER kosong.
+func registerOIDCRoutes(app fiber.Router, db *gorm.DB) {
+    if config.OIDCIssuer == "" {
+        return
+    }
+    if !auth.ValidRole(config.OIDCDefaultRole) {
+        log.Fatalf("OIDC_DEFAULT_ROLE %q tidak valid", config.OIDCDefaultRole)
+    }
+    provider := auth.NewOIDCProvider(auth.OIDCConfig{
+        Issuer:       config.OIDCIssuer,
+        ClientID:     config.OIDCClientID,
+        ClientSecret: config.OIDCClientSecret,
+        RedirectURL:  config.OIDCRedirectURL,
+        Scopes:       strings.Fields(config.OIDCScopes),
+        GroupsClaim:  config.OIDCGroupsClaim,
+    })
+    states := auth.NewOneTimeTokens(rdb, "oidc_state:", oidcStateTTL)
+    // Kode sekali pakai untuk menyerahkan hasil login ke frontend tanpa
+    // menaruh token akses di URL
+    loginCodes := auth.NewOneTimeTokens(rdb, "oidc_login:", time.Minute)
+    oidcIPLimit := newRateLimiter("oidc_ip", config.LoginRateLimitIP, config.LoginRateWindow, limitByIP)
+
+    // redirectToApp mengarahkan browser kembali ke halaman login frontend
+    redirectToApp := func(c *fiber.Ctx, params url.Values) error {
+        return c.Redirect(config.AppURL+"/login?"+params.Encode(), fiber.StatusFound)
+    }
+    ssoError := func(c *fiber.Ctx, message string) error {
+        return redirectToApp(c, url.Values{"sso_error": {message}})
+    }
+
+    // Mulai login SSO: simpan state, nonce dan code verifier lalu redirect ke provider
+    app.Get("/api/auth/oidc/login", oidcIPLimit, func(c *fiber.Ctx) error {
+        nonce, err := auth.NewTokenID()
+        if err != nil {
+            return ssoError(c, "Gagal memulai login SSO")
+        }
+        verifier, challenge, err := auth.NewPKCE()
+        if err != nil {
+            return ssoError(c, "Gagal memulai login SSO")
+        }
+        payload, _ := json.Marshal(oidcLoginState{Nonce: nonce, CodeVerifier: verifier})
+        state, err := states.Issue(payload)
+        if err != nil {
+            return ssoError(c, "Gagal memulai login SSO")
+        }
+        target, err := provider.AuthCodeURL(state, nonce, challenge)
+        if err != nil {
+            log.Printf("Gagal membuat URL login OIDC: %v", err)
+            return ssoError(c, "Provider SSO tidak dapat dihubungi")
+        }
+        setOIDCStateCookie(c, state)
+        return c.Redirect(target, fiber.StatusFound)
+    })
+
+    // Callback dari provider: tukar code, verifikasi ID token, buat/cari user
+    app.Get("/api/auth/oidc/callback", oidcIPLimit, func(c *fiber.Ctx) error {
+        // Cookie dicek sebelum state di Redis dipakai agar state curian tidak
+        // bisa dihabiskan dari browser lain
+        matches := oidcStateMatches(c)
+        clearOIDCStateCookie(c)
+        if errParam := c.Query("error"); errParam != "" {
+            return ssoError(c, "Login SSO dibatalkan atau ditolak")
+        }
+        if !matches {
+            return ssoError(c, "Sesi login SSO tidak valid atau sudah kedaluwarsa")
+        }
+        payload, err := states.Consume(c.Query("state"))
+        if err != nil {
+            return ssoError(c, "Sesi login SSO tidak valid atau sudah kedaluwarsa")
+        }
+        var state oidcLoginState
+        if err := json.Unmarshal(payload, &state); err != nil {
+            return ssoError(c, "Sesi login SSO tidak valid atau sudah kedaluwarsa")
+        }
+        identity, err := provider.Exchange(c.Query("code"), state.CodeVerifier, state.Nonce)
+        if err != nil {
+            log.Printf("Login OIDC gagal: %v", err)
+            return ssoError(c, "Login SSO gagal")
+        }
+        user, err := upsertOIDCUser(db, identity)
+        if errors.Is(err, errOIDCEmailTaken) {
+            return ssoError(c, "Email sudah terdaftar dengan akun lain, hubungi admin")
+        }
+        if err != nil {
+            log.Printf("Gagal menyimpan user OIDC %q: %v", identity.Subject, err)
+            return ssoError(c, "Login SSO gagal")
+        }
+        code, err := loginCodes.Issue([]byte(strconv.FormatUint(uint64(user.ID), 10)))
+        if err != nil {
+            return ssoError(c, "Login SSO gagal")
+        }
+        return redirectToApp(c, url.Values{"sso_code": {code}})
+    })
+
+    // Frontend menukar kode sekali pakai dengan token aplikasi biasa
+    app.Post("/api/auth/oidc/token", oidcIPLimit, func(c *fiber.Ctx) error {
+        var req struct {
+            Code string `json:"code"`
+        }
+        c.BodyParser(&req)
+        payload, err := loginCodes.Consume(req.Code)
+        if err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Kode login SSO tidak valid atau sudah kedaluwarsa",
+            })
+        }
+        userID, _ := strconv.ParseUint(string(payload), 10, 64)
+        var user User
+        if err := db.First(&user, uint(userID)).Error; err != nil {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Kode login SSO tidak valid atau sudah kedaluwarsa",
+            })
+        }
+        return beginSession(c, user)
+    })
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "testing"
+
+    "github.com/gofiber/fiber/v2"
+
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/utils"
+)
+
+func TestOIDCRoleFor(t *testing.T) {
+    saved := config
+    t.Cleanup(func() { config = saved })
+    config = &utils.Config{OIDCRoleMapping: "admin-ujian=admin, guru=teacher,pengawas=proctor,siswa=bukan-role"}
+
+    tests := []struct {
+        name   string
+        groups []string
+        want   string
+    }{
+        {name: "entri pertama yang cocok dipakai", groups: []string{"guru", "admin-ujian"}, want: auth.RoleAdmin},
+        {name: "spasi di mapping diabaikan", groups: []string{"guru"}, want: auth.RoleTeacher},
+        {name: "pengawas", groups: []string{"pengawas"}, want: auth.RoleProctor},
+        {name: "role tidak valid diabaikan", groups: []string{"siswa"}, want: ""},
+        {name: "grup tidak dikenal", groups: []string{"alumni"}, want: ""},
+        {name: "tanpa grup", groups: nil, want: ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := oidcRoleFor(tt.groups); got != tt.want {
+                t.Errorf("oidcRoleFor(%v) = %q, harus %q", tt.groups, got, tt.want)
+            }
+        })
+    }
+}
+
+// Callback dengan cookie state yang hilang atau berbeda harus ditolak sebelum
+// state di Redis disentuh (rdb sengaja nil di sini)
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+    saved, savedRdb := config, rdb
+    t.Cleanup(func() { config, rdb = saved, savedRdb })
+    config = &utils.Config{
+        AppURL:          "http://app.test",
+        OIDCIssuer:      "http://idp.test",
+        OIDCClientID:    "ujian",
+        OIDCDefaultRole: auth.RoleParticipant,
+    }
+    rdb = nil
+
+    app := fiber.New()
+    registerOIDCRoutes(app, nil)
+
+    tests := []struct {
+        name   string
+        cookie string
+    }{
+        {name: "tanpa cookie", cookie: ""},
+        {name: "cookie berbeda", cookie: oidcStateHash("state-lain")},
+        {name: "cookie berisi state mentah", cookie: "state-korban"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req := httptest.NewRequest("GET", "/api/auth/oidc/callback?state=state-korban&code=abc", nil)
+            if tt.cookie != "" {
+                req.Header.Set("Cookie", oidcStateCookie+"="+tt.cookie)
+            }
+            resp, err := app.Test(req)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if resp.StatusCode != fiber.StatusFound {
+                t.Fatalf("status = %d, harus %d", resp.StatusCode, fiber.StatusFound)
+            }
+            target, err := url.Parse(resp.Header.Get("Location"))
+            if err != nil || target.Query().Get("sso_error") == "" {
+                t.Errorf("redirect %q harus membawa sso_error", resp.Header.Get("Location"))
+            }
+            if !strings.Contains(resp.Header.Get("Set-Cookie"), oidcStateCookie+"=;") {
+                t.Errorf("cookie state harus dihapus, Set-Cookie = %q", resp.Header.Get("Set-Cookie"))
+            }
+        })
+    }
+}
+
+func TestOIDCStateMatches(t *testing.T) {
+    app := fiber.New()
+    app.Get("/", func(c *fiber.Ctx) error {
+        if oidcStateMatches(c) {
+            return c.SendStatus(fiber.StatusNoContent)
+        }
+        return c.SendStatus(fiber.StatusForbidden)
+    })
+    req := httptest.NewRequest("GET", "/?state=abc", nil)
+    req.Header.Set("Cookie", oidcStateCookie+"="+oidcStateHash("abc"))
+    resp, err := app.Test(req)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if resp.StatusCode != fiber.StatusNoContent {
+        t.Errorf("cookie yang cocok ditolak, status = %d", resp.StatusCode)
+    }
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "net/url"
+    "strconv"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+    "online-exam-app-backend/auth"
+    "online-exam-app-backend/mailer"
+)
+
+// registerPasswordRoutes mendaftarkan alur lupa password dan reset password
+func registerPasswordRoutes(app fiber.Router, db *gorm.DB) {
+    resetTokens := auth.NewOneTimeTokens(rdb, "password_reset:", config.PasswordResetTTL)
+    forgotIPLimit := newRateLimiter("forgot_ip", config.ForgotRateLimitIP, config.ForgotRateWindow, limitByIP)
+    forgotEmailLimit := newRateLimiter("forgot_email", config.ForgotRateLimitEmail, config.ForgotRateWindow, limitByEmail)
+
+    // Minta tautan reset password. Respons selalu sama agar tidak bisa
+    // dipakai untuk menebak email yang terdaftar.
+    app.Post("/api/password/forgot", forgotIPLimit, forgotEmailLimit, func(c *fiber.Ctx) error {
+        var req struct {
+            Email string `json:"email"`
+        }
+        if err := c.BodyParser(&req); err != nil || req.Email == "" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Email wajib diisi",
+            })
+        }
+
+        var user User
+        if err := db.Where("email = ?", req.Email).First(&user).Error; err == nil {
+            token, err := resetTokens.Issue([]byte(strconv.FormatUint(uint64(user.ID), 10)))
+            if err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "success": false,
+                    "message": "Gagal memproses permintaan reset password",
+                })
+            }
+            link := fmt.Sprintf("%s/forgot-password?token=%s", config.AppURL, url.QueryEscape(token))
+            msg := mailer.Message{
+                To:      user.Email,
+                Subject: "Reset password Ujian Online",
+                Body: fmt.Sprintf("Halo,\n\nKami menerima permintaan reset password untuk akun Anda.\n"+
+                    "Buka tautan berikut untuk membuat password baru (berlaku %d menit, hanya sekali pakai):\n\n%s\n\n"+
+                    "Abaikan email ini jika Anda tidak meminta reset password.\n",
+                    int(resetTokens.TTL().Minutes()), link),
+            }
+            // Dikirim di background agar waktu respons tidak membedakan email terdaftar
+            go func() {
+                if err := mail.Send(msg); err != nil {
+                    log.Printf("Gagal mengirim email reset password ke user %d: %v", user.ID, err)
+                }
+            }()
+        }
+
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Jika email terdaftar, tautan reset password telah dikirim",
+        })
+    })
+
+    // Reset password memakai token dari email
+    app.Post("/api/password/reset", func(c *fiber.Ctx) error {
+        var req struct {
+            Token    string `json:"token"`
+            Password string `json:"password"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        if req.Token == "" || req.Password == "" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Token dan password wajib diisi",
+            })
+        }
+
+        payload, err := resetTokens.Consume(req.Token)
+        if err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Token reset tidak valid atau sudah kedaluwarsa",
+            })
+        }
+        userID, _ := strconv.ParseUint(string(payload), 10, 64)
+        var user User
+        if err := db.First(&user, userID).Error; err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Token reset tidak valid atau sudah kedaluwarsa",
+            })
+        }
+
+        hash, err := passwords.Hash(req.Password)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal reset password",
+            })
+        }
+        if err := db.Model(&user).Update("password", hash).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal reset password",
+            })
+        }
+        // Sesi lama tidak boleh tetap aktif setelah password diganti
+        if err := sessions.RevokeAllForUser(user.ID); err != nil {
+            log.Printf("Gagal mencabut sesi user %d: %v", user.ID, err)
+        }
+
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "Password berhasil direset, silakan login kembali",
+        })
+    })
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+    "github.com/gofiber/fiber/v2"
+    "online-exam-app-backend/models"
+)
+
+// ParticipantQuestion adalah bentuk soal yang dikirim ke peserta selama ujian.
+// Kunci jawaban dan metadata penilaian sengaja tidak disertakan di sini.
+type ParticipantQuestion struct {
+    ID           uint     `json:"id"`
+    QuestionText string   `json:"question_text"`
+    Type         string   `json:"type"`
+    Options      []string `json:"options"`
+    // Prompts hanya untuk soal menjodohkan; Options berisi pasangan yang sudah diacak
+    Prompts []string `json:"prompts,omitempty"`
+}
+
+// AdminQuestion adalah bentuk soal lengkap untuk endpoint admin,
+// termasuk kunci jawaban dan bobot
+type AdminQuestion struct {
+    ID            uint     `json:"id"`
+    ExamID        uint     `json:"exam_id"`
+    QuestionText  string   `json:"question_text"`
+    CorrectAnswer string   `json:"correct_answer"`
+    Weight        int      `json:"weight"`
+    Type          string   `json:"type"`
+    Options       []string `json:"options"`
+    Prompts       []string `json:"prompts,omitempty"` // diisi otomatis dari answer_key.pairs
+    // AnswerKey untuk tipe selain pilihan_ganda, Rubric untuk soal esai dan jawaban singkat
+    AnswerKey *models.AnswerKey       `json:"answer_key,omitempty"`
+    Rubric    []models.RubricCriterion `json:"rubric,omitempty"`
+}
+
+func toParticipantQuestion(q models.Question) ParticipantQuestion {
+    options := q.Options
+    if options == nil {
+        options = []string{}
+    }
+    return ParticipantQuestion{
+        ID:           q.ID,
+        QuestionText: q.QuestionText,
+        Type:         q.Type,
+        Options:      options,
+        Prompts:      q.Prompts,
+    }
+}
+
+func toAdminQuestion(q models.Question) AdminQuestion {
+    return AdminQuestion{
+        ID:            q.ID,
+        ExamID:        q.ExamID,
+        QuestionText:  q.QuestionText,
+        CorrectAnswer: q.CorrectAnswer,
+        Weight:        q.Weight,
+        Type:          q.Type,
+        Options:       q.Options,
+        Prompts:       q.Prompts,
+        AnswerKey:     q.AnswerKey,
+        Rubric:        q.Rubric,
+    }
+}
+
+// applyTo menyalin isian admin ke model Question
+func (a AdminQuestion) applyTo(q *models.Question) {
+    q.ExamID = a.ExamID
+    q.QuestionText = a.QuestionText
+    q.CorrectAnswer = a.CorrectAnswer
+    q.Weight = a.Weight
+    q.Type = a.Type
+    q.Options = a.Options
+    q.AnswerKey = a.AnswerKey
+    q.Rubric = a.Rubric
+}
+
+// submitResponse adalah respons submit jawaban untuk peserta, termasuk saat
+// request diulang dengan Idempotency-Key yang sama
+func submitResponse(attempt models.Attempt) fiber.Map {
+    return fiber.Map{
+        "success":    true,
+        "message":    "Jawaban berhasil disubmit",
+        "attempt_id": attempt.ID,
+        "saved":      attempt.SavedCount,
+        "status":     attempt.ResultStatus,
+        "score":      visibleScore(attempt.ResultStatus, attempt.Score),
+        "max_score":  attempt.MaxScore,
+    }
+}
+
+// resultResponse adalah nilai akhir yang boleh dilihat peserta
+func resultResponse(result models.Result) fiber.Map {
+    return fiber.Map{
+        "success":   true,
+        "status":    result.Status,
+        "score":     visibleScore(result.Status, result.Score),
+        "max_score": result.MaxScore,
+        "attempts":  result.Attempts,
+        "graded_at": result.GradedAt,
+    }
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+    "encoding/json"
+    "testing"
+
+    "online-exam-app-backend/models"
+)
+
+// forbiddenKeys adalah field yang tidak boleh pernah sampai ke peserta
+var forbiddenKeys = []string{"correct_answer", "answer_key", "weight", "rubric"}
+
+func secretQuestions() []models.Question {
+    yes := true
+    number := 42.0
+    rubric := []models.RubricCriterion{{Criterion: "Isi", Points: 5}}
+    return []models.Question{
+        {ID: 1, ExamID: 1, QuestionText: "2 + 2 = ?", Type: models.QuestionSingleChoice, CorrectAnswer: "4", Weight: 3, Options: []string{"3", "4"}},
+        {ID: 2, ExamID: 1, QuestionText: "Pilih bilangan prima", Type: models.QuestionMultipleChoice, CorrectAnswer: "2, 3", Weight: 2,
+            Options: []string{"2", "3", "4"}, AnswerKey: &models.AnswerKey{Choices: []string{"2", "3"}, PartialCredit: models.CreditPartial}},
+        {ID: 3, ExamID: 1, QuestionText: "Bumi bulat", Type: models.QuestionTrueFalse, CorrectAnswer: "Benar",
+            Options: models.TrueFalseOptions, AnswerKey: &models.AnswerKey{Value: &yes}},
+        {ID: 4, ExamID: 1, QuestionText: "6 x 7 = ?", Type: models.QuestionNumeric, CorrectAnswer: "42", AnswerKey: &models.AnswerKey{Number: &number}},
+        {ID: 5, ExamID: 1, QuestionText: "Ibu kota Indonesia", Type: models.QuestionFillBlank, CorrectAnswer: "Jakarta",
+            AnswerKey: &models.AnswerKey{Accepted: []string{"Jakarta"}}},
+        {ID: 6, ExamID: 1, QuestionText: "Jodohkan", Type: models.QuestionMatching, CorrectAnswer: "A = 1; B = 2",
+            Prompts: []string{"A", "B"}, Options: []string{"2", "1"},
+            AnswerKey: &models.AnswerKey{Pairs: []models.MatchPair{{Left: "A", Right: "1"}, {Left: "B", Right: "2"}}}},
+        {ID: 7, ExamID: 1, QuestionText: "Urutkan", Type: models.QuestionOrdering, CorrectAnswer: "a, b",
+            Options: []string{"b", "a"}, AnswerKey: &models.AnswerKey{Sequence: []string{"a", "b"}}},
+        {ID: 8, ExamID: 1, QuestionText: "Jelaskan", Type: models.QuestionEssay, Weight: 5, Rubric: rubric},
+    }
+}
+
+// assertNoForbiddenKeys menelusuri seluruh objek JSON hasil serialisasi v
+func assertNoForbiddenKeys(t *testing.T, name string, v interface{}) {
+    t.Helper()
+    data, err := json.Marshal(v)
+    if err != nil {
+        t.Fatalf("%s: gagal serialisasi: %v", name, err)
+    }
+    var decoded interface{}
+    if err := json.Unmarshal(data, &decoded); err != nil {
+        t.Fatalf("%s: gagal membaca ulang JSON: %v", name, err)
+    }
+    var walk func(node interface{})
+    walk = func(node interface{}) {
+        switch n := node.(type) {
+        case map[string]interface{}:
+            for key, child := range n {
+                for _, forbidden := range forbiddenKeys {
+                    if key == forbidden {
+                        t.Errorf("%s: field %q bocor ke peserta: %s", name, key, data)
+                    }
+                }
+                walk(child)
+            }
+        case []interface{}:
+            for _, child := range n {
+                walk(child)
+            }
+        }
+    }
+    walk(decoded)
+}
+
+func TestParticipantQuestionHidesAnswerKey(t *testing.T) {
+    for _, q := range secretQuestions() {
+        assertNoForbiddenKeys(t, q.Type, toParticipantQuestion(q))
+        // Model Question juga tidak boleh membawa kunci jika terserialisasi langsung
+        assertNoForbiddenKeys(t, q.Type+" (model)", q)
+    }
+}
+
+func TestParticipantQuestionsResponseHidesAnswerKey(t *testing.T) {
+    questions := make([]ParticipantQuestion, 0)
+    for _, q := range secretQuestions() {
+        questions = append(questions, toParticipantQuestion(q))
+    }
+    assertNoForbiddenKeys(t, "GET /api/exam/:id/questions", questions)
+}
+
+func TestParticipantResultResponsesHideAnswerKey(t *testing.T) {
+    attempt := models.Attempt{ID: 1, ExamID: 1, ParticipantID: 2, Score: 3, MaxScore: 5, SavedCount: 2, ResultStatus: models.ResultGraded}
+    assertNoForbiddenKeys(t, "POST /api/answers/submit", submitResponse(attempt))
+
+    result := models.Result{ExamID: 1, ParticipantID: 2, Score: 3, MaxScore: 5, Attempts: 1, Status: models.ResultGraded}
+    assertNoForbiddenKeys(t, "GET /api/exam/:id/result", resultResponse(result))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "math/rand"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+    goredis "github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript mencatat satu request di sorted set per kunci dan
+// membuang entri yang sudah keluar dari window, semuanya dalam satu operasi
+// Redis yang atomik. Waktu diambil dari Redis agar semua proses memakai jam
+// yang sama. Mengembalikan 0 jika request diizinkan, atau sisa milidetik
+// sampai slot berikutnya tersedia.
+var slidingWindowScript = goredis.NewScript(`
+local now = redis.call('TIME')
+local nowMs = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+local window = tonumber(ARGV[1])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', nowMs - window)
+if redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[2]) then
+    redis.call('ZADD', KEYS[1], nowMs, ARGV[3])
+    redis.call('PEXPIRE', KEYS[1], window)
+    return 0
+end
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return math.max(tonumber(oldest[2]) + window - nowMs, 1)
+`)
+
+// newRateLimiter membuat limiter sliding window di Redis. max <= 0
+// mematikan limiter. Kunci kosong dari keyFn berarti request tidak dibatasi.
+func newRateLimiter(name string, max int, window time.Duration, keyFn func(c *fiber.Ctx) string) fiber.Handler {
+    if max <= 0 {
+        return func(c *fiber.Ctx) error { return c.Next() }
+    }
+    return func(c *fiber.Ctx) error {
+        key := keyFn(c)
+        if key == "" {
+            return c.Next()
+        }
+        member := strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(rand.Int63(), 36)
+        retryMs, err := slidingWindowScript.Run(context.Background(), rdb,
+            []string{"ratelimit:" + name + ":" + key}, window.Milliseconds(), max, member).Int64()
+        if err != nil {
+            // Redis yang bermasalah tidak boleh memblokir semua request
+            log.Printf("Rate limiter %s gagal: %v", name, err)
+            return c.Next()
+        }
+        if retryMs == 0 {
+            return c.Next()
+        }
+        c.Set(fiber.HeaderRetryAfter, strconv.FormatInt((retryMs+999)/1000, 10))
+        return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+            "success": false,
+            "message": "Terlalu banyak permintaan, silakan coba lagi nanti",
+        })
+    }
+}
+
+// limitByIP membatasi request per alamat IP
+func limitByIP(c *fiber.Ctx) string {
+    return c.IP()
+}
+
+// limitByEmail membatasi request per akun berdasarkan email di body request
+func limitByEmail(c *fiber.Ctx) string {
+    var req struct {
+        Email string `json:"email"`
+    }
+    if err := c.BodyParser(&req); err != nil {
+        return ""
+    }
+    return strings.ToLower(strings.TrimSpace(req.Email))
+}
+
+// limitByUser membatasi request per user yang sudah login (setelah authMiddleware)
+func limitByUser(c *fiber.Ctx) string {
+    userID, ok := c.Locals("user_id").(float64)
+    if !ok {
+        return ""
+    }
+    return fmt.Sprintf("%d", uint(userID))
+}
+
+// accountLocked mengirim respons 429 untuk akun yang sedang dikunci
+func accountLocked(c *fiber.Ctx, retryAfter time.Duration) error {
+    seconds := int(retryAfter.Seconds())
+    if retryAfter%time.Second != 0 {
+        seconds++
+    }
+    c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+    return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+        "success": false,
+        "message": "Akun dikunci sementara karena terlalu banyak percobaan login gagal",
+        "retry_after": seconds,
+    })
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+    "gorm.io/gorm"
+)
+
+func TestRateLimiterSlidingWindow(t *testing.T) {
+    app := newTestApp(t, func(app fiber.Router, db *gorm.DB) {
+        limit := newRateLimiter("test", 2, time.Minute, limitByIP)
+        app.Get("/", limit, func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+    })
+    for i := 1; i <= 3; i++ {
+        resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if i <= 2 {
+            if resp.StatusCode != fiber.StatusNoContent {
+                t.Fatalf("request %d: status = %d, harus 204", i, resp.StatusCode)
+            }
+            continue
+        }
+        if resp.StatusCode != fiber.StatusTooManyRequests {
+            t.Fatalf("request %d: status = %d, harus 429", i, resp.StatusCode)
+        }
+        if got := resp.Header.Get(fiber.HeaderRetryAfter); got == "" || got == "0" {
+            t.Errorf("Retry-After = %q, harus detik sampai slot berikutnya", got)
+        }
+    }
+}
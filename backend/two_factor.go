@@ -0,0 +1,399 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+    "github.com/golang-jwt/jwt/v4"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+    "online-exam-app-backend/auth"
+)
+
+// Challenge token dari langkah password harus ditukar dalam waktu ini
+const mfaChallengeTTL = 5 * time.Minute
+
+// Jumlah kode pemulihan yang dibuat setiap kali 2FA diaktifkan
+const recoveryCodeCount = 10
+
+// issueMFAChallenge membuat challenge token untuk langkah kedua login
+func issueMFAChallenge(user User) (string, error) {
+    jti, err := auth.NewTokenID()
+    if err != nil {
+        return "", err
+    }
+    return tokens.Sign(jwt.MapClaims{
+        "typ":     auth.TokenTypeMFAChallenge,
+        "user_id": user.ID,
+        "jti":     jti,
+        "exp":     time.Now().Add(mfaChallengeTTL).Unix(),
+    })
+}
+
+// verifySecondFactor memeriksa kode TOTP atau kode pemulihan milik user.
+// Kode TOTP yang sudah dipakai ditolak, dan kode pemulihan dihapus setelah dipakai.
+func verifySecondFactor(db *gorm.DB, user *User, code string) (bool, error) {
+    if step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+        key := fmt.Sprintf("totp_used:%d:%d", user.ID, step)
+        fresh, err := rdb.SetNX(ctx, key, "1", 3*time.Minute).Result()
+        if err != nil {
+            return false, err
+        }
+        return fresh, nil
+    }
+    // Baris user dikunci agar kode pemulihan yang sama tidak bisa dipakai
+    // oleh dua request bersamaan
+    var ok bool
+    err := db.Transaction(func(tx *gorm.DB) error {
+        var locked User
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "recovery_codes").First(&locked, user.ID).Error; err != nil {
+            return err
+        }
+        remaining, match := auth.MatchRecoveryCode(locked.RecoveryCodes, code)
+        if !match {
+            return nil
+        }
+        locked.RecoveryCodes = remaining
+        if err := tx.Model(&locked).Select("recovery_codes").Updates(&locked).Error; err != nil {
+            return err
+        }
+        ok = true
+        user.RecoveryCodes = remaining
+        return nil
+    })
+    return ok, err
+}
+
+// checkSecondFactor memverifikasi kode 2FA dengan penguncian akun yang sama
+// seperti login password. Jika kode ditolak, respons error sudah dikirim dan
+// nilai pertama false; wrongStatus dipakai untuk kode yang salah.
+func checkSecondFactor(c *fiber.Ctx, db *gorm.DB, user *User, code string, wrongStatus int) (bool, error) {
+    locked, err := loginGuard.LockedFor(user.Email)
+    if err != nil {
+        log.Printf("Gagal memeriksa penguncian login: %v", err)
+    }
+    if locked > 0 {
+        return false, accountLocked(c, locked)
+    }
+    ok, err := verifySecondFactor(db, user, code)
+    if err != nil {
+        return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "success": false,
+            "message": "Gagal memverifikasi kode",
+        })
+    }
+    if !ok {
+        lockout, err := loginGuard.RecordFailure(user.Email)
+        if err != nil {
+            log.Printf("Gagal mencatat login gagal: %v", err)
+        }
+        if lockout > 0 {
+            return false, accountLocked(c, lockout)
+        }
+        return false, c.Status(wrongStatus).JSON(fiber.Map{
+            "success": false,
+            "message": "Kode verifikasi salah",
+        })
+    }
+    if err := loginGuard.Reset(user.Email); err != nil {
+        log.Printf("Gagal mereset penghitung login gagal: %v", err)
+    }
+    return true, nil
+}
+
+// registerTwoFactorRoutes mendaftarkan langkah kedua login dan pengelolaan 2FA
+func registerTwoFactorRoutes(app fiber.Router, db *gorm.DB) {
+    mfaIPLimit := newRateLimiter("mfa_ip", config.LoginRateLimitIP, config.LoginRateWindow, limitByIP)
+
+    // Langkah kedua login: tukar challenge token + kode TOTP dengan token akses
+    app.Post("/api/login/2fa", mfaIPLimit, func(c *fiber.Ctx) error {
+        var req struct {
+            ChallengeToken string `json:"challenge_token"`
+            Code           string `json:"code"`
+        }
+        if err := c.BodyParser(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Challenge token dan kode wajib diisi",
+            })
+        }
+        claims, err := tokens.Parse(req.ChallengeToken)
+        if typ, _ := claims["typ"].(string); err != nil || typ != auth.TokenTypeMFAChallenge {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Sesi login tidak valid atau sudah kedaluwarsa",
+            })
+        }
+        userID, _ := claims["user_id"].(float64)
+        jti, _ := claims["jti"].(string)
+        var user User
+        if err := db.First(&user, uint(userID)).Error; err != nil || !user.TOTPEnabled {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Sesi login tidak valid atau sudah kedaluwarsa",
+            })
+        }
+
+        // Kode yang salah ikut dihitung ke penguncian akun yang sama dengan password
+        if ok, err := checkSecondFactor(c, db, &user, req.Code, fiber.StatusUnauthorized); !ok {
+            return err
+        }
+
+        // Challenge token hanya boleh ditukar sekali
+        fresh, err := rdb.SetNX(ctx, "mfa_challenge_used:"+jti, "1", mfaChallengeTTL).Result()
+        if err != nil || !fresh {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "success": false,
+                "message": "Sesi login tidak valid atau sudah kedaluwarsa",
+            })
+        }
+        return loginResponse(c, user, true)
+    })
+
+    // Status 2FA user yang sedang login
+    app.Get("/api/2fa", authMiddleware, func(c *fiber.Ctx) error {
+        user, err := currentUser(c, db)
+        if err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User tidak ditemukan",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success":                  true,
+            "enabled":                  user.TOTPEnabled,
+            "required":                 config.RequireAdmin2FA && user.isAdmin(),
+            "recovery_codes_remaining": len(user.RecoveryCodes),
+        })
+    })
+
+    // Mulai pendaftaran: buat secret baru yang belum aktif sampai dikonfirmasi
+    app.Post("/api/2fa/setup", authMiddleware, func(c *fiber.Ctx) error {
+        user, err := currentUser(c, db)
+        if err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User tidak ditemukan",
+            })
+        }
+        if user.TOTPEnabled {
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "2FA sudah aktif",
+            })
+        }
+        secret, err := auth.GenerateTOTPSecret()
+        if err == nil {
+            err = db.Model(&user).Update("totp_secret", secret).Error
+        }
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menyiapkan 2FA",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success":     true,
+            "secret":      secret,
+            "otpauth_url": auth.TOTPURI(config.TOTPIssuer, user.Email, secret),
+        })
+    })
+
+    // Konfirmasi pendaftaran dengan kode pertama dari aplikasi authenticator
+    app.Post("/api/2fa/enable", authMiddleware, func(c *fiber.Ctx) error {
+        var req struct {
+            Code string `json:"code"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        user, err := currentUser(c, db)
+        if err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User tidak ditemukan",
+            })
+        }
+        if user.TOTPEnabled {
+            return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+                "success": false,
+                "message": "2FA sudah aktif",
+            })
+        }
+        if user.TOTPSecret == "" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Jalankan setup 2FA terlebih dahulu",
+            })
+        }
+        if _, ok := auth.ValidateTOTP(user.TOTPSecret, req.Code, time.Now()); !ok {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Kode verifikasi salah",
+            })
+        }
+        codes, hashes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+        if err == nil {
+            user.TOTPEnabled = true
+            user.RecoveryCodes = hashes
+            err = db.Model(&user).Select("totp_enabled", "recovery_codes").Updates(&user).Error
+        }
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mengaktifkan 2FA",
+            })
+        }
+        // Sesi lain tanpa 2FA dicabut; sesi ini diganti token yang sudah lolos 2FA
+        if err := sessions.RevokeAllForUser(user.ID); err != nil {
+            log.Printf("Gagal mencabut sesi user %d: %v", user.ID, err)
+        }
+        pair, err := sessions.Issue(user.identityWithMFA(true))
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal membuat token",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success":        true,
+            "message":        "2FA berhasil diaktifkan, simpan kode pemulihan di tempat aman",
+            "recovery_codes": codes,
+            "token":          pair.AccessToken,
+            "refresh_token":  pair.RefreshToken,
+            "expires_in":     pair.ExpiresIn,
+        })
+    })
+
+    // Buat ulang kode pemulihan; kode lama tidak berlaku lagi
+    app.Post("/api/2fa/recovery-codes", authMiddleware, func(c *fiber.Ctx) error {
+        var req struct {
+            Code string `json:"code"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        user, err := currentUser(c, db)
+        if err != nil || !user.TOTPEnabled {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "2FA belum aktif",
+            })
+        }
+        if ok, err := checkSecondFactor(c, db, &user, req.Code, fiber.StatusBadRequest); !ok {
+            return err
+        }
+        codes, hashes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+        if err == nil {
+            user.RecoveryCodes = hashes
+            err = db.Model(&user).Select("recovery_codes").Updates(&user).Error
+        }
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal membuat kode pemulihan",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success":        true,
+            "recovery_codes": codes,
+        })
+    })
+
+    // Nonaktifkan 2FA dengan password dan kode TOTP/pemulihan
+    app.Post("/api/2fa/disable", authMiddleware, func(c *fiber.Ctx) error {
+        var req struct {
+            Password string `json:"password"`
+            Code     string `json:"code"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Format data tidak valid",
+            })
+        }
+        user, err := currentUser(c, db)
+        if err != nil || !user.TOTPEnabled {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "2FA belum aktif",
+            })
+        }
+        if config.RequireAdmin2FA && user.isAdmin() {
+            return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+                "success": false,
+                "message": "2FA wajib untuk akun admin",
+            })
+        }
+        if ok, _ := passwords.Verify(user.Password, req.Password); !ok {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "success": false,
+                "message": "Password salah",
+            })
+        }
+        if ok, err := checkSecondFactor(c, db, &user, req.Code, fiber.StatusBadRequest); !ok {
+            return err
+        }
+        if err := disableTwoFactor(db, &user); err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal menonaktifkan 2FA",
+            })
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "2FA berhasil dinonaktifkan",
+        })
+    })
+}
+
+// registerAdminTwoFactorRoutes mendaftarkan reset 2FA di /api/admin/users
+func registerAdminTwoFactorRoutes(admin fiber.Router, db *gorm.DB) {
+    // Reset 2FA untuk user yang kehilangan perangkat dan kode pemulihan
+    admin.Post("/users/:id/reset-2fa", requirePermission(auth.PermUsersManage), func(c *fiber.Ctx) error {
+        var user User
+        if err := db.First(&user, c.Params("id")).Error; err != nil {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "success": false,
+                "message": "User tidak ditemukan",
+            })
+        }
+        if err := disableTwoFactor(db, &user); err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "success": false,
+                "message": "Gagal mereset 2FA",
+            })
+        }
+        if err := sessions.RevokeAllForUser(user.ID); err != nil {
+            log.Printf("Gagal mencabut sesi user %d: %v", user.ID, err)
+        }
+        return c.JSON(fiber.Map{
+            "success": true,
+            "message": "2FA user berhasil direset",
+        })
+    })
+}
+
+// disableTwoFactor menghapus secret dan kode pemulihan user
+func disableTwoFactor(db *gorm.DB, user *User) error {
+    user.TOTPEnabled = false
+    user.TOTPSecret = ""
+    user.RecoveryCodes = nil
+    return db.Model(user).Select("totp_enabled", "totp_secret", "recovery_codes").Updates(user).Error
+}
+
+// currentUser memuat user dari token pada request
+func currentUser(c *fiber.Ctx, db *gorm.DB) (User, error) {
+    var user User
+    userID, _ := c.Locals("user_id").(float64)
+    err := db.First(&user, uint(userID)).Error
+    return user, err
+}
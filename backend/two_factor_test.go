@@ -0,0 +1,59 @@
+package main
+
+import (
+    "testing"
+
+    "github.com/gofiber/fiber/v2"
+    "online-exam-app-backend/auth"
+)
+
+// enableTestTwoFactor mengaktifkan 2FA untuk user dan mengembalikan kode pemulihannya
+func enableTestTwoFactor(t *testing.T, user *User) []string {
+    t.Helper()
+    codes, hashes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+    if err != nil {
+        t.Fatal(err)
+    }
+    user.TOTPEnabled, user.TOTPSecret, user.RecoveryCodes = true, "JBSWY3DPEHPK3PXP", hashes
+    if err := db.Model(user).Select("totp_enabled", "totp_secret", "recovery_codes").Updates(user).Error; err != nil {
+        t.Fatal(err)
+    }
+    return codes
+}
+
+func TestRecoveryCodeIsSingleUse(t *testing.T) {
+    newTestApp(t, registerTwoFactorRoutes)
+    user, _ := newTestParticipant(t, "pulih@example.com")
+    codes := enableTestTwoFactor(t, &user)
+
+    // Salinan user yang dimuat sebelum kode dipakai mewakili request bersamaan
+    stale := user
+    if ok, err := verifySecondFactor(db, &user, codes[0]); err != nil || !ok {
+        t.Fatalf("kode pemulihan pertama ditolak: ok=%v err=%v", ok, err)
+    }
+    if ok, err := verifySecondFactor(db, &stale, codes[0]); err != nil || ok {
+        t.Fatalf("kode pemulihan yang sama diterima dua kali: ok=%v err=%v", ok, err)
+    }
+    if len(user.RecoveryCodes) != recoveryCodeCount-1 {
+        t.Errorf("sisa kode = %d, harus %d", len(user.RecoveryCodes), recoveryCodeCount-1)
+    }
+}
+
+func TestTwoFactorManagementCountsWrongCodes(t *testing.T) {
+    app := newTestApp(t, registerTwoFactorRoutes)
+    user, token := newTestParticipant(t, "kunci@example.com")
+    codes := enableTestTwoFactor(t, &user)
+
+    for i := 1; i < config.LoginMaxFailures; i++ {
+        if status, body := callJSON(t, app, "POST", "/api/2fa/recovery-codes", token, fiber.Map{"code": "salah"}); status != fiber.StatusBadRequest {
+            t.Fatalf("percobaan %d: status = %d, body = %v", i, status, body)
+        }
+    }
+    if status, body := callJSON(t, app, "POST", "/api/2fa/disable", token, fiber.Map{"password": "rahasia123", "code": "salah"}); status != fiber.StatusTooManyRequests {
+        t.Fatalf("percobaan terakhir harus mengunci akun: status = %d, body = %v", status, body)
+    }
+    // Kode yang benar pun ditolak selama akun terkunci
+    if status, body := callJSON(t, app, "POST", "/api/2fa/recovery-codes", token, fiber.Map{"code": codes[0]}); status != fiber.StatusTooManyRequests {
+        t.Fatalf("akun terkunci: status = %d, body = %v", status, body)
+    }
+}
@@ -3,6 +3,8 @@ package utils
 import (
     "fmt"
     "os"
+    "strconv"
+    "time"
 )
 
 type Config struct {
@@ -19,7 +21,75 @@ type Config struct {
     RedisPass string
     
     // JWT
-    JWTSecret string
+    JWTSecret         string
+    JWTAlgorithm      string
+    JWTKeyID          string
+    JWTPrivateKeyFile string
+    JWTVerifyKeys     string
+    AccessTokenTTL    time.Duration
+    RefreshTokenTTL   time.Duration
+    
+    // Password hashing
+    BcryptCost int
+    
+    // Mail
+    MailDriver  string
+    MailFrom    string
+    MailFileDir string
+    SMTPHost    string
+    SMTPPort    string
+    SMTPUser    string
+    SMTPPass    string
+    
+    // URL frontend untuk tautan di email
+    AppURL string
+    
+    // Password reset & email verification
+    PasswordResetTTL time.Duration
+    EmailVerifyTTL   time.Duration
+    
+    // Rate limit (sliding window, disimpan di Redis)
+    LoginRateLimitIP      int
+    LoginRateLimitAccount int
+    LoginRateWindow       time.Duration
+    RegisterRateLimit     int
+    RegisterRateWindow    time.Duration
+    DraftRateLimit        int
+    DraftRateWindow       time.Duration
+    ForgotRateLimitIP     int
+    ForgotRateLimitEmail  int
+    ForgotRateWindow      time.Duration
+    
+    // Penguncian akun setelah login gagal berulang
+    LoginMaxFailures    int
+    LoginFailureWindow  time.Duration
+    LoginLockoutTTL     time.Duration
+    
+    // Two-factor authentication (TOTP)
+    TOTPIssuer      string
+    RequireAdmin2FA bool
+    
+    // OpenID Connect SSO (nonaktif jika OIDC_ISSUER kosong)
+    OIDCIssuer       string
+    OIDCClientID     string
+    OIDCClientSecret string
+    OIDCRedirectURL  string
+    OIDCScopes       string
+    OIDCGroupsClaim  string
+    OIDCRoleMapping  string
+    OIDCDefaultRole  string
+    
+    // Deadline ujian: toleransi submit setelah deadline dan interval worker
+    // yang mengumpulkan otomatis attempt yang lewat deadline
+    DeadlineGrace      time.Duration
+    AutoSubmitInterval time.Duration
+    // Lama draft jawaban disimpan setelah deadline attempt
+    DraftRetention time.Duration
+    
+    // Penyimpanan draft jawaban: redis, postgres, atau hybrid (Redis dengan
+    // write-behind ke Postgres setiap DraftFlushInterval)
+    DraftStorage       string
+    DraftFlushInterval time.Duration
     
     // SSL/TLS
     SSLCert string
@@ -41,7 +111,68 @@ func LoadConfig() *Config {
         RedisPass: getEnv("REDIS_PASS", ""),
         
         // JWT
-        JWTSecret: getEnv("JWT_SECRET", "your-secret-key"),
+        JWTSecret:         getEnv("JWT_SECRET", ""), // wajib diisi (minimal 32 byte) jika HS256
+        JWTAlgorithm:      getEnv("JWT_ALGORITHM", "HS256"),
+        JWTKeyID:          getEnv("JWT_KEY_ID", "default"),
+        JWTPrivateKeyFile: getEnv("JWT_PRIVATE_KEY_FILE", ""),
+        JWTVerifyKeys:     getEnv("JWT_VERIFY_KEYS", ""),
+        AccessTokenTTL:    getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+        RefreshTokenTTL:   getEnvDuration("JWT_REFRESH_TTL", 7*24*time.Hour),
+        
+        // Password hashing
+        BcryptCost: getEnvInt("BCRYPT_COST", 12),
+        
+        // Mail
+        MailDriver:  getEnv("MAIL_DRIVER", "log"),
+        MailFrom:    getEnv("MAIL_FROM", "no-reply@localhost"),
+        MailFileDir: getEnv("MAIL_FILE_DIR", "./mail"),
+        SMTPHost:    getEnv("SMTP_HOST", "localhost"),
+        SMTPPort:    getEnv("SMTP_PORT", "1025"),
+        SMTPUser:    getEnv("SMTP_USER", ""),
+        SMTPPass:    getEnv("SMTP_PASS", ""),
+        
+        AppURL: getEnv("APP_URL", "http://localhost:3001"),
+        
+        PasswordResetTTL: getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+        EmailVerifyTTL:   getEnvDuration("EMAIL_VERIFY_TTL", 48*time.Hour),
+        
+        // Rate limit; nilai 0 mematikan limit terkait
+        LoginRateLimitIP:      getEnvInt("LOGIN_RATE_LIMIT_IP", 20),
+        LoginRateLimitAccount: getEnvInt("LOGIN_RATE_LIMIT_ACCOUNT", 10),
+        LoginRateWindow:       getEnvDuration("LOGIN_RATE_WINDOW", time.Minute),
+        RegisterRateLimit:     getEnvInt("REGISTER_RATE_LIMIT", 5),
+        RegisterRateWindow:    getEnvDuration("REGISTER_RATE_WINDOW", time.Hour),
+        // Frontend menyimpan draft per soal tiap 30 detik, jadi batasnya per user
+        // harus cukup longgar untuk ujian dengan banyak soal
+        DraftRateLimit:        getEnvInt("DRAFT_RATE_LIMIT", 300),
+        DraftRateWindow:       getEnvDuration("DRAFT_RATE_WINDOW", time.Minute),
+        // Lupa password mengirim email, jadi dibatasi per IP dan per alamat tujuan
+        ForgotRateLimitIP:     getEnvInt("FORGOT_RATE_LIMIT_IP", 10),
+        ForgotRateLimitEmail:  getEnvInt("FORGOT_RATE_LIMIT_EMAIL", 3),
+        ForgotRateWindow:      getEnvDuration("FORGOT_RATE_WINDOW", time.Hour),
+        
+        LoginMaxFailures:   getEnvInt("LOGIN_MAX_FAILURES", 5),
+        LoginFailureWindow: getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+        LoginLockoutTTL:    getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+        
+        TOTPIssuer:      getEnv("TOTP_ISSUER", "Ujian Online"),
+        RequireAdmin2FA: getEnvBool("REQUIRE_ADMIN_2FA", false),
+        
+        OIDCIssuer:       getEnv("OIDC_ISSUER", ""),
+        OIDCClientID:     getEnv("OIDC_CLIENT_ID", ""),
+        OIDCClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
+        OIDCRedirectURL:  getEnv("OIDC_REDIRECT_URL", "http://localhost:3000/api/auth/oidc/callback"),
+        OIDCScopes:       getEnv("OIDC_SCOPES", "openid email profile"),
+        OIDCGroupsClaim:  getEnv("OIDC_GROUPS_CLAIM", "groups"),
+        OIDCRoleMapping:  getEnv("OIDC_ROLE_MAPPING", ""),
+        OIDCDefaultRole:  getEnv("OIDC_DEFAULT_ROLE", "participant"),
+        
+        DeadlineGrace:      getEnvDuration("DEADLINE_GRACE", 30*time.Second),
+        AutoSubmitInterval: getEnvDuration("AUTO_SUBMIT_INTERVAL", 30*time.Second),
+        DraftRetention:     getEnvDuration("DRAFT_RETENTION", time.Hour),
+        
+        DraftStorage:       getEnv("DRAFT_STORAGE", "hybrid"),
+        DraftFlushInterval: getEnvDuration("DRAFT_FLUSH_INTERVAL", 10*time.Second),
         
         // SSL/TLS
         SSLCert: getEnv("SSL_CERT", "./cert.pem"),
@@ -67,4 +198,31 @@ func getEnv(key, fallback string) string {
         return value
     }
     return fallback
-} 
\ No newline at end of file
+}
+
+func getEnvInt(key string, fallback int) int {
+    if value, exists := os.LookupEnv(key); exists {
+        if n, err := strconv.Atoi(value); err == nil {
+            return n
+        }
+    }
+    return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+    if value, exists := os.LookupEnv(key); exists {
+        if d, err := time.ParseDuration(value); err == nil {
+            return d
+        }
+    }
+    return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+    if value, exists := os.LookupEnv(key); exists {
+        if b, err := strconv.ParseBool(value); err == nil {
+            return b
+        }
+    }
+    return fallback
+}
@@ -1,13 +1,34 @@
 import React, { useState, useEffect } from 'react';
 import { BrowserRouter as Router, Routes, Route, Navigate } from 'react-router-dom';
+import axios from 'axios';
 import './App.css';
 import Login from './components/login';
 import Dashboard from './components/dashboard';
 import Register from './components/Register';
 import ExamPage from './components/ExamPage';
 import ForgotPassword from './components/ForgotPassword';
+import VerifyEmail from './components/VerifyEmail';
 import AdminPanel from './components/AdminPanel';
 
+const API_URL = process.env.NODE_ENV === 'production'
+    ? (process.env.REACT_APP_API_URL || 'https://api.yourdomain.com')
+    : 'http://localhost:3000';
+
+// Access token berumur pendek: saat 401, tukar refresh token lalu ulangi request sekali
+axios.interceptors.response.use(null, async (error) => {
+    const original = error.config;
+    const refreshToken = localStorage.getItem('refresh_token');
+    if (error.response?.status !== 401 || !refreshToken || original._retried || original.url.includes('/api/token/refresh')) {
+        return Promise.reject(error);
+    }
+    original._retried = true;
+    const res = await axios.post(`${API_URL}/api/token/refresh`, { refresh_token: refreshToken });
+    localStorage.setItem('token', res.data.token);
+    localStorage.setItem('refresh_token', res.data.refresh_token);
+    original.headers.Authorization = `Bearer ${res.data.token}`;
+    return axios(original);
+});
+
 function App() {
     const [user, setUser] = useState(null);
 
@@ -25,7 +46,18 @@ function App() {
         setUser(loggedInUser);
     };
 
-    const handleLogout = () => {
+    const handleLogout = async () => {
+        // Cabut token di server, bukan hanya menghapus data di browser
+        try {
+            await axios.post(`${API_URL}/api/logout`, {
+                refresh_token: localStorage.getItem('refresh_token')
+            }, {
+                headers: { Authorization: `Bearer ${localStorage.getItem('token')}` }
+            });
+        } catch (err) {
+            console.error('Logout error:', err);
+        }
+        ['token', 'refresh_token', 'role', 'email'].forEach(key => localStorage.removeItem(key));
         setUser(null);
     };
 
@@ -38,7 +70,8 @@ function App() {
                     <Route path="/register" element={<Register />} />
                     <Route path="/exam/:id" element={user ? <ExamPage /> : <Navigate to="/login" />} />
                     <Route path="/forgot-password" element={<ForgotPassword />} />
-                    <Route path="/admin" element={user && user.role === 'admin' ? <AdminPanel /> : <Navigate to="/dashboard" />} />
+                    <Route path="/verify-email" element={<VerifyEmail />} />
+                    <Route path="/admin" element={user && ['admin', 'teacher', 'proctor'].includes(user.role) ? <AdminPanel /> : <Navigate to="/dashboard" />} />
                     <Route path="/" element={<Navigate to="/login" />} />
                 </Routes>
             </div>
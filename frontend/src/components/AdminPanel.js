@@ -67,7 +67,9 @@ function AdminPanel({ onLogout }) {
 
   const handleSubmit = async e => {
     e.preventDefault();
-    if (!form.exam_id || !form.question_text || !form.correct_answer || !form.type) {
+    // Soal esai dan jawaban singkat dinilai manual sehingga tidak punya kunci jawaban
+    const manual = form.type === 'esai' || form.type === 'jawaban_singkat';
+    if (!form.exam_id || !form.question_text || (!manual && !form.correct_answer) || !form.type) {
       setNotif('Semua field wajib diisi!');
       setNotifType('error');
       return;
@@ -77,11 +79,27 @@ function AdminPanel({ onLogout }) {
       setNotifType('error');
       return;
     }
+    if (form.type === 'numerik' && isNaN(parseFloat(form.correct_answer))) {
+      setNotif('Jawaban numerik harus berupa angka!');
+      setNotifType('error');
+      return;
+    }
+    // Benar/salah dan numerik disimpan sebagai kunci jawaban terstruktur
+    let answerKey;
+    if (form.type === 'benar_salah') answerKey = { value: form.correct_answer === 'Benar' };
+    if (form.type === 'numerik') answerKey = { number: parseFloat(form.correct_answer), tolerance: parseFloat(form.tolerance) || 0 };
+    if (form.type === 'isian') {
+      // Jawaban lain yang diterima (diatur lewat API) tetap dipertahankan
+      const prev = form.answer_key || {};
+      answerKey = { ...prev, accepted: [form.correct_answer, ...(prev.accepted || []).slice(1)] };
+    }
     const payload = {
       ...form,
       exam_id: parseInt(form.exam_id, 10),
-      options: form.type === 'pilihan_ganda' ? form.options.filter(opt => opt.trim()) : undefined
+      options: form.type === 'pilihan_ganda' ? form.options.filter(opt => opt.trim()) : undefined,
+      answer_key: answerKey
     };
+    delete payload.tolerance;
     try {
       let res;
       if (isEdit) {
@@ -116,7 +134,8 @@ function AdminPanel({ onLogout }) {
   const handleEdit = q => {
     setForm({
       ...q,
-      options: Array.isArray(q.options) && q.options.length ? q.options : ['', '', '', '']
+      options: Array.isArray(q.options) && q.options.length ? q.options : ['', '', '', ''],
+      tolerance: q.answer_key && q.answer_key.tolerance ? String(q.answer_key.tolerance) : ''
     });
     setIsEdit(true);
   };
@@ -178,7 +197,11 @@ function AdminPanel({ onLogout }) {
             <input name="question_text" placeholder="Pertanyaan" value={form.question_text} onChange={handleChange} required />{' '}
             <select name="type" value={form.type} onChange={handleChange} required style={{marginRight: 10}}>
               <option value="pilihan_ganda">Pilihan Ganda</option>
+              <option value="benar_salah">Benar / Salah</option>
+              <option value="numerik">Numerik</option>
               <option value="isian">Isian Singkat</option>
+              <option value="jawaban_singkat">Jawaban Singkat (dinilai manual)</option>
+              <option value="esai">Esai (dinilai manual)</option>
             </select>
             {form.type === 'pilihan_ganda' && (
               <div style={{margin:'10px 0'}}>
@@ -191,6 +214,19 @@ function AdminPanel({ onLogout }) {
                 </select>
               </div>
             )}
+            {form.type === 'benar_salah' && (
+              <select name="correct_answer" value={form.correct_answer} onChange={handleChange} required style={{marginRight:5}}>
+                <option value="">Pilih Jawaban Benar</option>
+                <option value="Benar">Benar</option>
+                <option value="Salah">Salah</option>
+              </select>
+            )}
+            {form.type === 'numerik' && (
+              <span>
+                <input name="correct_answer" type="number" step="any" placeholder="Jawaban Benar" value={form.correct_answer} onChange={handleChange} required style={{marginRight:5}} />
+                <input name="tolerance" type="number" step="any" min="0" placeholder="Toleransi" value={form.tolerance || ''} onChange={handleChange} style={{marginRight:5}} />
+              </span>
+            )}
             {form.type === 'isian' && (
               <input name="correct_answer" placeholder="Jawaban Benar" value={form.correct_answer} onChange={handleChange} required style={{marginRight:5}} />
             )}
@@ -15,6 +15,7 @@ const ExamPage = () => {
     const [notif, setNotif] = useState('');
     const [notifType, setNotifType] = useState('');
     const [examStarted, setExamStarted] = useState(false);
+    const [attemptId, setAttemptId] = useState(null);
 
     // Start exam and get server time
     useEffect(() => {
@@ -24,11 +25,19 @@ const ExamPage = () => {
                     headers: { Authorization: `Bearer ${localStorage.getItem('token')}` }
                 });
                 if (res.data.success) {
+                    setTimeLeft(res.data.remaining_time);
+                    setAttemptId(res.data.attempt_id);
+                    // Pulihkan jawaban yang tersimpan otomatis saat melanjutkan attempt
+                    if (res.data.resumed) {
+                        const drafts = await axios.get(`${API_URL}/api/exam/${id}/drafts`, {
+                            headers: { Authorization: `Bearer ${localStorage.getItem('token')}` }
+                        });
+                        setAnswers(drafts.data.drafts || {});
+                    }
                     setExamStarted(true);
-                    setTimeLeft(res.data.duration);
                 }
             } catch (err) {
-                setNotif('Gagal memulai ujian');
+                setNotif(err.response?.data?.message || 'Gagal memulai ujian');
                 setNotifType('error');
             }
         };
@@ -80,7 +89,7 @@ const ExamPage = () => {
         const autoSave = setInterval(() => {
             Object.entries(answers).forEach(([questionId, answerText]) => {
                 axios.post(`${API_URL}/api/answers/draft`, {
-                    question_id: questionId,
+                    question_id: parseInt(questionId),
                     answer_text: answerText
                 }, {
                     headers: { Authorization: `Bearer ${localStorage.getItem('token')}` }
@@ -101,9 +110,69 @@ const ExamPage = () => {
         setAnswers(prev => ({ ...prev, [questionId]: answer }));
     };
 
+    // Jawaban pilihan ganda kompleks dikirim sebagai array JSON, misalnya ["A","C"]
+    const selectedOptions = (questionId) => {
+        try {
+            const parsed = JSON.parse(answers[questionId] || '[]');
+            return Array.isArray(parsed) ? parsed : [];
+        } catch {
+            return [];
+        }
+    };
+
+    const handleToggleOption = (questionId, option) => {
+        const current = selectedOptions(questionId);
+        const next = current.includes(option)
+            ? current.filter(o => o !== option)
+            : [...current, option];
+        handleAnswerChange(questionId, JSON.stringify(next));
+    };
+
+    // Jawaban menjodohkan dikirim sebagai objek JSON {"pernyataan": "pasangan"}
+    const matchedPairs = (questionId) => {
+        try {
+            const parsed = JSON.parse(answers[questionId] || '{}');
+            return parsed && typeof parsed === 'object' && !Array.isArray(parsed) ? parsed : {};
+        } catch {
+            return {};
+        }
+    };
+
+    const handleMatch = (questionId, prompt, option) => {
+        handleAnswerChange(questionId, JSON.stringify({ ...matchedPairs(questionId), [prompt]: option }));
+    };
+
+    // Jawaban mengurutkan dikirim sebagai array JSON sesuai urutan peserta
+    const currentOrder = (q) => {
+        try {
+            const parsed = JSON.parse(answers[q.id] || 'null');
+            if (Array.isArray(parsed) && parsed.length === q.options.length) return parsed;
+        } catch {
+            // jawaban rusak diabaikan, kembali ke urutan awal
+        }
+        return q.options;
+    };
+
+    const handleMove = (q, index, delta) => {
+        const order = [...currentOrder(q)];
+        const target = index + delta;
+        if (target < 0 || target >= order.length) return;
+        [order[index], order[target]] = [order[target], order[index]];
+        handleAnswerChange(q.id, JSON.stringify(order));
+    };
+
     const handleSubmit = async () => {
         if (!window.confirm('Yakin ingin mengumpulkan jawaban?')) return;
 
+        // Satu kunci per attempt, disimpan sampai submit berhasil agar percobaan
+        // ulang setelah error jaringan mendapat respons yang sama dari server
+        const keyName = `submit_key_${attemptId}`;
+        let idempotencyKey = localStorage.getItem(keyName);
+        if (!idempotencyKey) {
+            idempotencyKey = `${id}-${attemptId}-${Date.now()}-${Math.random().toString(36).slice(2)}`;
+            localStorage.setItem(keyName, idempotencyKey);
+        }
+
         try {
             // Submit semua jawaban sebagai final
             const answersArray = Object.entries(answers).map(([questionId, answerText]) => ({
@@ -111,21 +180,22 @@ const ExamPage = () => {
                 answer_text: answerText
             }));
 
-            await axios.post(`${API_URL}/api/answers/submit`, answersArray, {
-                headers: { Authorization: `Bearer ${localStorage.getItem('token')}` }
+            const res = await axios.post(`${API_URL}/api/answers/submit`, {
+                exam_id: parseInt(id),
+                answers: answersArray
+            }, {
+                headers: {
+                    Authorization: `Bearer ${localStorage.getItem('token')}`,
+                    'Idempotency-Key': idempotencyKey
+                }
             });
 
+            localStorage.removeItem(keyName);
             setNotif('Jawaban berhasil dikumpulkan');
             setNotifType('success');
             
-            // Hitung skor
-            let currentScore = 0;
-            questions.forEach(q => {
-                if (answers[q.id] === q.correct_answer) {
-                    currentScore += q.weight || 1; // Gunakan bobot soal jika ada
-                }
-            });
-            setScore(currentScore);
+            // Skor dihitung oleh server
+            setScore({ value: res.data.score, max: res.data.max_score });
         } catch (err) {
             setNotif('Gagal mengumpulkan jawaban');
             setNotifType('error');
@@ -136,7 +206,9 @@ const ExamPage = () => {
         return (
             <div className="exam-page">
                 <h1>Hasil Ujian</h1>
-                <h2>Skor Anda: {score} dari {questions.reduce((acc, q) => acc + (q.weight || 1), 0)}</h2>
+                {score.value === null
+                    ? <h2>Jawaban esai sedang dinilai guru. Skor akan tersedia setelah penilaian selesai.</h2>
+                    : <h2>Skor Anda: {score.value} dari {score.max}</h2>}
                 <Link to="/dashboard">Kembali ke Dashboard</Link>
             </div>
         );
@@ -174,7 +246,66 @@ const ExamPage = () => {
                         <b>{index + 1}. {q.text}</b>
                         {q.weight > 1 && <span style={{ color: '#666', fontSize: '0.9em' }}> (Bobot: {q.weight})</span>}
                     </p>
-                    {q.options.map(option => (
+                    {q.type === 'esai' && (
+                        <textarea
+                            rows={6}
+                            style={{ width: '100%' }}
+                            value={answers[q.id] || ''}
+                            onChange={(e) => handleAnswerChange(q.id, e.target.value)}
+                        />
+                    )}
+                    {q.type === 'jawaban_singkat' && (
+                        <input
+                            type="text"
+                            value={answers[q.id] || ''}
+                            onChange={(e) => handleAnswerChange(q.id, e.target.value)}
+                        />
+                    )}
+                    {(q.type === 'isian' || q.type === 'numerik') && (
+                        <input
+                            type="text"
+                            inputMode={q.type === 'numerik' ? 'decimal' : undefined}
+                            value={answers[q.id] || ''}
+                            onChange={(e) => handleAnswerChange(q.id, e.target.value)}
+                        />
+                    )}
+                    {q.type === 'pilihan_ganda_kompleks' && q.options.map(option => (
+                        <div key={option}>
+                            <input
+                                type="checkbox"
+                                value={option}
+                                checked={selectedOptions(q.id).includes(option)}
+                                onChange={() => handleToggleOption(q.id, option)}
+                            />
+                            <label>{option}</label>
+                        </div>
+                    ))}
+                    {q.type === 'menjodohkan' && (q.prompts || []).map(prompt => (
+                        <div key={prompt} style={{ marginBottom: '5px' }}>
+                            <label style={{ marginRight: '10px' }}>{prompt}</label>
+                            <select
+                                value={matchedPairs(q.id)[prompt] || ''}
+                                onChange={(e) => handleMatch(q.id, prompt, e.target.value)}
+                            >
+                                <option value="">Pilih pasangan</option>
+                                {q.options.map(option => (
+                                    <option key={option} value={option}>{option}</option>
+                                ))}
+                            </select>
+                        </div>
+                    ))}
+                    {q.type === 'mengurutkan' && (
+                        <ol>
+                            {currentOrder(q).map((item, idx, order) => (
+                                <li key={item}>
+                                    {item}{' '}
+                                    <button type="button" disabled={idx === 0} onClick={() => handleMove(q, idx, -1)}>↑</button>
+                                    <button type="button" disabled={idx === order.length - 1} onClick={() => handleMove(q, idx, 1)}>↓</button>
+                                </li>
+                            ))}
+                        </ol>
+                    )}
+                    {(q.type === 'pilihan_ganda' || q.type === 'benar_salah') && q.options.map(option => (
                         <div key={option}>
                             <input
                                 type="radio"
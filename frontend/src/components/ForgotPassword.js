@@ -1,14 +1,74 @@
-import React from 'react';
-import { Link } from 'react-router-dom';
+import React, { useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import axios from 'axios';
+
+const API_URL = process.env.NODE_ENV === 'production'
+    ? (process.env.REACT_APP_API_URL || 'https://api.yourdomain.com')
+    : 'http://localhost:3000';
 
 const ForgotPassword = () => {
+    const [searchParams] = useSearchParams();
+    const token = searchParams.get('token');
+    const [email, setEmail] = useState('');
+    const [password, setPassword] = useState('');
+    const [message, setMessage] = useState('');
+    const [error, setError] = useState('');
+
+    // Langkah 1: minta tautan reset lewat email
+    const handleForgot = async (e) => {
+        e.preventDefault();
+        setError('');
+        try {
+            const res = await axios.post(`${API_URL}/api/password/forgot`, { email });
+            setMessage(res.data.message);
+        } catch (err) {
+            setError(err.response?.data?.message || 'Gagal mengirim permintaan');
+        }
+    };
+
+    // Langkah 2: buat password baru memakai token dari email
+    const handleReset = async (e) => {
+        e.preventDefault();
+        setError('');
+        try {
+            const res = await axios.post(`${API_URL}/api/password/reset`, { token, password });
+            setMessage(res.data.message);
+        } catch (err) {
+            setError(err.response?.data?.message || 'Gagal reset password');
+        }
+    };
+
     return (
         <div className="forgot-password-container">
             <h1>Lupa Password</h1>
-            <p>Fitur untuk reset password akan diimplementasikan di sini.</p>
-            <Link to="/login">Kembali ke Login</Link>
+            {token ? (
+                <form onSubmit={handleReset}>
+                    <input
+                        type="password"
+                        placeholder="Password baru"
+                        value={password}
+                        onChange={(e) => setPassword(e.target.value)}
+                    />
+                    <button type="submit">Simpan Password</button>
+                </form>
+            ) : (
+                <form onSubmit={handleForgot}>
+                    <input
+                        type="email"
+                        placeholder="Email"
+                        value={email}
+                        onChange={(e) => setEmail(e.target.value)}
+                    />
+                    <button type="submit">Kirim Tautan Reset</button>
+                </form>
+            )}
+            {message && <div style={{ color: 'green', marginTop: '10px' }}>{message}</div>}
+            {error && <div style={{ color: 'red', marginTop: '10px' }}>{error}</div>}
+            <div style={{ marginTop: '10px' }}>
+                <Link to="/login">Kembali ke Login</Link>
+            </div>
         </div>
     );
 };
 
-export default ForgotPassword; 
\ No newline at end of file
+export default ForgotPassword;
@@ -6,7 +6,7 @@ function UserManagement() {
   const [users, setUsers] = useState([]);
   const [token, setToken] = useState('');
   const [loading, setLoading] = useState(true);
-  const [form, setForm] = useState({ id: '', email: '', role: 'user' });
+  const [form, setForm] = useState({ id: '', email: '', role: 'participant' });
   const [isEdit, setIsEdit] = useState(false);
   const [notif, setNotif] = useState('');
   const [notifType, setNotifType] = useState('');
@@ -113,7 +113,7 @@ function UserManagement() {
   };
 
   const handleCancel = () => {
-    setForm({ id: '', email: '', role: 'user' });
+    setForm({ id: '', email: '', role: 'participant' });
     setIsEdit(false);
   };
 
@@ -149,7 +149,9 @@ function UserManagement() {
             onChange={handleChange}
             style={{ marginRight: 10 }}
           >
-            <option value="user">User</option>
+            <option value="participant">Peserta</option>
+            <option value="proctor">Pengawas</option>
+            <option value="teacher">Guru</option>
             <option value="admin">Admin</option>
           </select>
 
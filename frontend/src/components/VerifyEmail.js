@@ -0,0 +1,32 @@
+import React, { useEffect, useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import axios from 'axios';
+
+const API_URL = process.env.NODE_ENV === 'production'
+    ? (process.env.REACT_APP_API_URL || 'https://api.yourdomain.com')
+    : 'http://localhost:3000';
+
+const VerifyEmail = () => {
+    const [searchParams] = useSearchParams();
+    const [message, setMessage] = useState('Memverifikasi email...');
+    const [success, setSuccess] = useState(false);
+
+    useEffect(() => {
+        axios.post(`${API_URL}/api/email/verify`, { token: searchParams.get('token') })
+            .then(res => {
+                setMessage(res.data.message);
+                setSuccess(true);
+            })
+            .catch(err => setMessage(err.response?.data?.message || 'Verifikasi gagal'));
+    }, [searchParams]);
+
+    return (
+        <div className="verify-email-container">
+            <h1>Verifikasi Email</h1>
+            <p style={{ color: success ? 'green' : 'inherit' }}>{message}</p>
+            <Link to="/login">Kembali ke Login</Link>
+        </div>
+    );
+};
+
+export default VerifyEmail;
@@ -22,14 +22,14 @@ const Dashboard = ({ user, onLogout }) => {
                 <h2>Selamat datang, {user.email}!</h2>
                 <button onClick={onLogout} style={{ height: 'fit-content' }}>Logout</button>
             </div>
-            {user.role === 'admin' && (
+            {['admin', 'teacher', 'proctor'].includes(user.role) && (
                 <button style={{margin:'16px 0'}} onClick={() => navigate('/admin')}>Panel Admin</button>
             )}
             <h1>DASHBOARD PESERTA</h1>
             {exams.map((exam) => (
                 <div key={exam.id} className="exam-card">
                     <h2>{exam.title}</h2>
-                    <p>Durasi: {exam.duration} menit</p>
+                    <p>Durasi: {Math.round(exam.duration / 60)} menit</p>
                     <button onClick={() => handleStartExam(exam)}>Mulai Ujian</button>
                 </div>
             ))}
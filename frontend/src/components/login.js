@@ -1,28 +1,64 @@
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
 import axios from 'axios';
-import { useNavigate } from 'react-router-dom';
+import { useNavigate, useSearchParams } from 'react-router-dom';
+
+const API_URL = process.env.NODE_ENV === 'production'
+    ? (process.env.REACT_APP_API_URL || 'https://api.yourdomain.com')
+    : 'http://localhost:3000';
 
 const Login = ({ onLogin }) => {
     const [email, setEmail] = useState('');
     const [password, setPassword] = useState('');
     const [error, setError] = useState('');
+    const [challengeToken, setChallengeToken] = useState(null);
+    const [code, setCode] = useState('');
     const navigate = useNavigate();
+    const [searchParams] = useSearchParams();
+
+    const completeLogin = (data) => {
+        localStorage.setItem('token', data.token);
+        localStorage.setItem('refresh_token', data.refresh_token);
+        localStorage.setItem('role', data.user.role);
+        localStorage.setItem('email', data.user.email);
+        onLogin({ email: data.user.email, role: data.user.role, token: data.token });
+        // Selain peserta, semua role memakai panel admin
+        if (data.user.role !== 'participant') {
+            navigate('/admin');
+        } else {
+            navigate('/dashboard');
+        }
+    };
+
+    const handleLoginResponse = (data) => {
+        // Akun dengan 2FA aktif perlu memasukkan kode TOTP
+        if (data.mfa_required) {
+            setChallengeToken(data.challenge_token);
+            return;
+        }
+        completeLogin(data);
+    };
+
+    // Kembali dari login SSO: tukar kode sekali pakai dengan token aplikasi
+    useEffect(() => {
+        const ssoError = searchParams.get('sso_error');
+        const ssoCode = searchParams.get('sso_code');
+        if (ssoError) {
+            setError(ssoError);
+        } else if (ssoCode) {
+            axios.post(`${API_URL}/api/auth/oidc/token`, { code: ssoCode })
+                .then(res => handleLoginResponse(res.data))
+                .catch(err => setError('Login SSO gagal: ' + (err.response?.data?.message || err.message)));
+        }
+        // eslint-disable-next-line react-hooks/exhaustive-deps
+    }, [searchParams]);
 
     const handleSubmit = async (e) => {
         e.preventDefault();
         setError('');
         try {
-            const res = await axios.post('http://localhost:3000/api/login', { email, password });
+            const res = await axios.post(`${API_URL}/api/login`, { email, password });
             if (res.data && res.data.success) {
-                localStorage.setItem('token', res.data.token);
-                localStorage.setItem('role', res.data.user.role);
-                localStorage.setItem('email', res.data.user.email);
-                onLogin({ email: res.data.user.email, role: res.data.user.role, token: res.data.token });
-                if (res.data.user.role === 'admin') {
-                    navigate('/admin');
-                } else {
-                    navigate('/dashboard');
-                }
+                handleLoginResponse(res.data);
             } else {
                 setError(res.data.message || 'Login gagal');
             }
@@ -31,6 +67,40 @@ const Login = ({ onLogin }) => {
         }
     };
 
+    const handleVerifyCode = async (e) => {
+        e.preventDefault();
+        setError('');
+        try {
+            const res = await axios.post(`${API_URL}/api/login/2fa`, {
+                challenge_token: challengeToken,
+                code
+            });
+            completeLogin(res.data);
+        } catch (err) {
+            setError('Verifikasi gagal: ' + (err.response?.data?.message || err.message));
+        }
+    };
+
+    if (challengeToken) {
+        return (
+            <div className="login-container">
+                <h1>Verifikasi 2 Langkah</h1>
+                <form onSubmit={handleVerifyCode}>
+                    <input
+                        type="text"
+                        inputMode="numeric"
+                        autoComplete="one-time-code"
+                        placeholder="Kode authenticator atau kode pemulihan"
+                        value={code}
+                        onChange={(e) => setCode(e.target.value)}
+                    />
+                    <button type="submit">Verifikasi</button>
+                </form>
+                {error && <div style={{ color: 'red', marginTop: '8px' }}>{error}</div>}
+            </div>
+        );
+    }
+
     return (
         <div className="login-container">
             <h1>UJIAN ONLINE</h1>
@@ -49,6 +119,9 @@ const Login = ({ onLogin }) => {
                 />
                 <button type="submit">Login</button>
             </form>
+            <div style={{ marginTop: '10px' }}>
+                <a href={`${API_URL}/api/auth/oidc/login`}>Login dengan SSO Sekolah</a>
+            </div>
             {error && <div style={{ color: 'red', marginTop: '8px' }}>{error}</div>}
             <div style={{ marginTop: '10px' }}>
                 <a href="/register">Daftar Baru</a>
@@ -1,29 +1,128 @@
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255),
+    email VARCHAR(255) UNIQUE NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'participant', -- admin, teacher, proctor, participant
+    email_verified BOOLEAN NOT NULL DEFAULT TRUE,
+    totp_secret TEXT,
+    totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    recovery_codes JSONB, -- hash SHA-256 kode pemulihan
+    oidc_subject TEXT UNIQUE
+);
+
 CREATE TABLE exams (
     id SERIAL PRIMARY KEY,
     title VARCHAR(255) NOT NULL,
     description TEXT,
-    duration INT,
+    duration INT, -- detik
+    owner_id INT,
+    opens_at TIMESTAMPTZ,
+    closes_at TIMESTAMPTZ,
+    max_attempts INT NOT NULL DEFAULT 0, -- 0 berarti tidak dibatasi
+    grading_policy VARCHAR(10) NOT NULL DEFAULT 'last', -- best, last, average
     created_at TIMESTAMP DEFAULT NOW()
 );
+CREATE INDEX idx_exams_owner_id ON exams (owner_id);
+
+CREATE TABLE exam_overrides (
+    id SERIAL PRIMARY KEY,
+    exam_id INT NOT NULL REFERENCES exams(id),
+    user_id INT NOT NULL,
+    extra_time INT, -- detik
+    note TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_exam_override_user ON exam_overrides (exam_id, user_id);
 
 CREATE TABLE questions (
     id SERIAL PRIMARY KEY,
     exam_id INT REFERENCES exams(id),
     question_text TEXT NOT NULL,
-    correct_answer VARCHAR(255) NOT NULL
+    correct_answer TEXT NOT NULL DEFAULT '',
+    weight INT DEFAULT 1,
+    type VARCHAR(50) DEFAULT 'pilihan_ganda',
+    options JSONB,
+    prompts JSONB, -- pernyataan kiri soal menjodohkan
+    answer_key JSONB, -- kunci jawaban terstruktur
+    rubric JSONB -- rubrik soal yang dinilai manual
+);
+
+CREATE TABLE attempts (
+    id SERIAL PRIMARY KEY,
+    exam_id INT NOT NULL REFERENCES exams(id),
+    participant_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress', -- in_progress, submitted, expired
+    started_at TIMESTAMP,
+    deadline TIMESTAMP,
+    submitted_at TIMESTAMP,
+    idempotency_key VARCHAR(255),
+    saved_count INT,
+    auto_submitted BOOLEAN NOT NULL DEFAULT FALSE,
+    score DOUBLE PRECISION,
+    max_score DOUBLE PRECISION,
+    result_status VARCHAR(10) NOT NULL DEFAULT 'graded', -- pending, graded
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
 );
+CREATE INDEX idx_attempt_exam_participant ON attempts (exam_id, participant_id);
 
 CREATE TABLE answers (
     id SERIAL PRIMARY KEY,
+    attempt_id INT REFERENCES attempts(id),
     participant_id INT,
     question_id INT REFERENCES questions(id),
-    answer_text VARCHAR(255) NOT NULL,
-    submitted_at TIMESTAMP DEFAULT NOW()
+    answer_text TEXT NOT NULL DEFAULT '',
+    submitted_at TIMESTAMP DEFAULT NOW(),
+    is_draft BOOLEAN NOT NULL DEFAULT FALSE
 );
+CREATE UNIQUE INDEX idx_answer_attempt_question ON answers (attempt_id, question_id);
 
-CREATE TABLE users (
+CREATE TABLE answer_grades (
     id SERIAL PRIMARY KEY,
-    email VARCHAR(255) UNIQUE NOT NULL,
-    password VARCHAR(255) NOT NULL,
-    role VARCHAR(20) NOT NULL DEFAULT 'user'
-);
\ No newline at end of file
+    answer_id INT UNIQUE NOT NULL REFERENCES answers(id),
+    grader_id INT NOT NULL,
+    points DOUBLE PRECISION,
+    rubric_scores JSONB, -- poin per kriteria rubrik
+    comment TEXT,
+    graded_at TIMESTAMP
+);
+
+CREATE TABLE results (
+    id SERIAL PRIMARY KEY,
+    exam_id INT NOT NULL REFERENCES exams(id),
+    participant_id INT NOT NULL,
+    score DOUBLE PRECISION,
+    max_score DOUBLE PRECISION,
+    attempts INT, -- jumlah attempt yang dinilai
+    status VARCHAR(10) NOT NULL DEFAULT 'graded', -- pending, graded
+    graded_at TIMESTAMP
+);
+CREATE UNIQUE INDEX idx_result_exam_participant ON results (exam_id, participant_id);
+
+CREATE TABLE groups (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    owner_id INT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX idx_groups_owner_id ON groups (owner_id);
+
+CREATE TABLE group_members (
+    id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL REFERENCES groups(id),
+    user_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE UNIQUE INDEX idx_group_member ON group_members (group_id, user_id);
+CREATE INDEX idx_group_members_user_id ON group_members (user_id);
+
+CREATE TABLE exam_groups (
+    id SERIAL PRIMARY KEY,
+    exam_id INT NOT NULL REFERENCES exams(id),
+    group_id INT NOT NULL REFERENCES groups(id)
+);
+CREATE UNIQUE INDEX idx_exam_group ON exam_groups (exam_id, group_id);
+CREATE INDEX idx_exam_groups_group_id ON exam_groups (group_id);